@@ -60,6 +60,7 @@
   var gridContext;
 
   var nickname;
+  var playerId;
   var lobby;
   var grid = [
     ["G", "T", "S", "S"],
@@ -72,6 +73,13 @@
 
   var flashReset = null;
 
+  var wordStatusMessages = {
+    tooShort: "too short",
+    duplicate: "already found",
+    notOnBoard: "not on the board",
+    notInDictionary: "not in the dictionary"
+  };
+
   window.addEventListener("load", function() {
     containerElem = document.createElement("div");
     containerElem.style.position = "absolute";
@@ -103,7 +111,7 @@
     gridElem = document.createElement("canvas");
     gridElem.addEventListener("click", function(event) {
       if(!inputElem.disabled) {
-        var squareSize = gridElem.width / 4;
+        var squareSize = gridElem.width / grid.length;
         var squarePadding = squareSize / 20;
         var squareRounding = squareSize / 10;
         var glyphSize = squareSize * 0.7;
@@ -278,7 +286,7 @@
   }
 
   function renderGrid() {
-    var squareSize = gridElem.width / 4;
+    var squareSize = gridElem.width / grid.length;
     var squarePadding = squareSize / 20;
     var squareRounding = squareSize / 10;
     var glyphSize = squareSize * 0.7;
@@ -292,8 +300,8 @@
     gridContext.textAlign = "center";
     gridContext.textBaseline = "middle";
 
-    for(var i = 0; i < 4; i ++) {
-      for(var j = 0; j < 4; j ++) {
+    for(var i = 0; i < grid.length; i ++) {
+      for(var j = 0; j < grid[i].length; j ++) {
         drawRoundedRectangle(gridContext,
           j * squareSize + squarePadding, i * squareSize + squarePadding,
           squareSize - 2 * squarePadding, squareSize - 2 * squarePadding, squareRounding);
@@ -304,6 +312,15 @@
     }
   }
 
+  function findPlayer(id) {
+    for(var i = 0; i < lobby.players.length; i ++) {
+      if(lobby.players[i].id === id) {
+        return lobby.players[i];
+      }
+    }
+    return null;
+  }
+
   function renderInterface() {
     if(nickname) {
       nicknameElem.removeChild(nicknameElem.firstChild);
@@ -319,7 +336,8 @@
       inputElem.value = "";
     }
 
-    if(lobby && lobby.state === "betweenGames" && lobby.players[nickname].readied === false) {
+    var me = lobby ? findPlayer(playerId) : null;
+    if(me && lobby.state === "betweenGames" && me.readied === false) {
       lobbyReadyButtonElem.disabled = false;
     } else {
       lobbyReadyButtonElem.disabled = true;
@@ -344,25 +362,26 @@
         lobbyPlayerListElem.removeChild(lobbyPlayerListElem.lastChild);
       }
 
-      var renderPlayerName = function(playerName) {
-        var player = lobby.players[playerName];
-
+      var renderPlayer = function(player) {
         lobbyPlayerListElem.appendChild(document.createElement("div"));
         lobbyPlayerListElem.lastChild.style.paddingBottom = "2.5%";
-        lobbyPlayerListElem.lastChild.appendChild(document.createTextNode(playerName + " (" + player.score + ")"));
-        if(playerName === nickname) {
+        var label = player.nickname + " (" + player.score + ")";
+        if(player.rating) {
+          label += " [" + player.rating + "]";
+        }
+        lobbyPlayerListElem.lastChild.appendChild(document.createTextNode(label));
+        if(player.id === playerId) {
           lobbyPlayerListElem.lastChild.style.fontWeight = "bold";
         }
       };
 
-      renderPlayerName(nickname);
-
-      var playerNames = Object.keys(lobby.players);
-      playerNames.sort();
+      if(me) {
+        renderPlayer(me);
+      }
 
-      playerNames.forEach(function(playerName) {
-        if(playerName !== nickname) {
-          renderPlayerName(playerName);
+      lobby.players.forEach(function(player) {
+        if(player.id !== playerId) {
+          renderPlayer(player);
         }
       });
 
@@ -394,13 +413,12 @@
       wordlistContainerElem.lastChild.style.marginTop = "0";
       wordlistContainerElem.lastChild.appendChild(document.createTextNode(text));
     } else if(lobby) {
-      var outputPlayerResult = function(playerName) {
-        var player = lobby.players[playerName];
-        if(player.result) {
+      var outputPlayerResult = function(player) {
+        if(player && player.result) {
           wordlistContainerElem.appendChild(document.createElement("p"));
           wordlistContainerElem.lastChild.style.fontWeight = "bold";
           wordlistContainerElem.lastChild.style.marginTop = "0";
-          wordlistContainerElem.lastChild.appendChild(document.createTextNode(playerName + "'s words (" + player.result.score + "):"));
+          wordlistContainerElem.lastChild.appendChild(document.createTextNode(player.nickname + "'s words (" + player.result.score + "):"));
           wordlistContainerElem.appendChild(document.createElement("p"));
           wordlistContainerElem.lastChild.style.marginTop = "0";
 
@@ -425,13 +443,11 @@
         }
       };
 
-      outputPlayerResult(nickname);
+      outputPlayerResult(findPlayer(playerId));
 
-      var playerNames = Object.keys(lobby.players);
-      playerNames.sort();
-      playerNames.forEach(function(playerName) {
-        if(playerName !== nickname) {
-          outputPlayerResult(playerName);
+      lobby.players.forEach(function(player) {
+        if(player.id !== playerId) {
+          outputPlayerResult(player);
         }
       });
 
@@ -510,6 +526,7 @@
   window.updateInterface = function(data) {
     if(data.type === "state") {
       nickname = data.nickname;
+      playerId = data.id;
       lobby = data.lobby;
       if(data.lobby && data.lobby.grid) {
         grid = data.lobby.grid;
@@ -519,8 +536,14 @@
       if(!lobby || lobby.state !== "inGame") {
         words = [];
       }
+    } else if(data.type === "chat") {
+      flashMessage(data.from + ": " + data.text, false);
     } else if(data.type === "word") {
-      words.push(data.word);
+      if(data.status === "valid") {
+        words.push(data.word);
+      } else {
+        flashMessage(data.word + ": " + (wordStatusMessages[data.status] || data.status), true);
+      }
     }
 
     if(data.message) {
@@ -2,6 +2,7 @@
   "use strict";
 
   var socket;
+  var protocolVersion = 1;
 
   function sendJSON(object) {
     var string = JSON.stringify(object);
@@ -16,8 +17,41 @@
     }
   }
 
-  window.joinLobby = function(lobbyName) {
-    sendJSON({"command": "join", "lobbyName": lobbyName});
+  window.joinLobby = function(lobbyName, size, dictionary, passphrase, rated) {
+    var request = {"command": "join", "lobbyName": lobbyName};
+    if(size) {
+      request.size = Number(size);
+    }
+    if(dictionary) {
+      request.dictionary = dictionary;
+    }
+    if(passphrase) {
+      request.passphrase = passphrase;
+    }
+    if(rated) {
+      request.rated = true;
+    }
+    sendJSON(request);
+  };
+
+  window.configureLobby = function(settings) {
+    var request = {"command": "configure"};
+    for(var key in settings) {
+      request[key] = Number(settings[key]);
+    }
+    sendJSON(request);
+  };
+
+  window.spectateLobby = function(lobbyName, passphrase) {
+    var request = {"command": "spectate", "lobbyName": lobbyName};
+    if(passphrase) {
+      request.passphrase = passphrase;
+    }
+    sendJSON(request);
+  };
+
+  window.listLobbies = function() {
+    sendJSON({"command": "list"});
   };
 
   window.partLobby = function() {
@@ -28,21 +62,82 @@
     sendJSON({"command": "ready"});
   };
 
+  window.nick = function(nickname) {
+    sendJSON({"command": "nick", "nickname": nickname});
+  };
+
+  window.replay = function(gameId, speed) {
+    var request = {"command": "replay", "gameId": gameId};
+    if(speed) {
+      request.speed = Number(speed);
+    }
+    sendJSON(request);
+  };
+
+  window.challenge = function() {
+    sendJSON({"command": "challenge"});
+  };
+
+  window.chat = function(text) {
+    sendJSON({"command": "chat", "text": text});
+  };
+
+  function postAccount(path, body) {
+    var request = new XMLHttpRequest();
+    request.open("POST", path);
+    request.setRequestHeader("Content-Type", "application/json");
+    request.addEventListener("load", function() {
+      var data;
+      try {
+        data = JSON.parse(request.responseText);
+      } catch(e) {
+        data = null;
+      }
+      if(request.status >= 400) {
+        updateInterface({type: "error", message: data ? data.message : "Something went wrong. Please try again."});
+        return;
+      }
+      window.location.reload();
+    });
+    request.send(body ? JSON.stringify(body) : null);
+  }
+
+  window.register = function(username, password) {
+    postAccount("/accounts/register", {"username": username, "password": password});
+  };
+
+  window.login = function(username, password) {
+    postAccount("/accounts/login", {"username": username, "password": password});
+  };
+
+  window.logout = function() {
+    postAccount("/accounts/logout");
+  };
+
   window.word = function(word) {
     sendJSON({"command": "word", "word": word});
   };
 
-  window.addEventListener("load", function() {
+  var resumeToken = null;
+  var reconnectAttempts = 0;
+  var maxReconnectAttempts = 5;
+
+  function connect() {
     var proto = (window.location.protocol === "http:") ? "ws:" : "wss:";
-    var path = proto + "//" + window.location.hostname + ":" + window.location.port + "/engine";
+    var path = proto + "//" + window.location.hostname + ":" + window.location.port + "/engine?version=" + protocolVersion;
+    if(resumeToken) {
+      path += "&resume=" + encodeURIComponent(resumeToken);
+    }
 
     socket = new WebSocket(path);
 
     socket.addEventListener("close", function() {
-      updateInterface({type: "error", message: "Something went wrong. Please refresh the page."});
-    });
-
-    socket.addEventListener("error", function() {
+      if(resumeToken && reconnectAttempts < maxReconnectAttempts) {
+        reconnectAttempts ++;
+        updateInterface({type: "error", message: "Connection lost; reconnecting..."});
+        window.setTimeout(connect, 1000 * reconnectAttempts);
+        return;
+      }
       updateInterface({type: "error", message: "Something went wrong. Please refresh the page."});
     });
 
@@ -53,7 +148,13 @@
       } catch(e) {
         return;
       }
+      if(data.resumeToken) {
+        resumeToken = data.resumeToken;
+        reconnectAttempts = 0;
+      }
       updateInterface(data);
     });
-  });
+  }
+
+  window.addEventListener("load", connect);
 })();
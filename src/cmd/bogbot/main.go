@@ -1,159 +1,157 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"math/rand"
-	"net/url"
 	"os"
 	"os/signal"
+	"path"
 	"syscall"
 	"time"
 
+	"internal/dictionary"
 	"internal/grid"
+	"internal/language"
 	"internal/log"
-
-	"github.com/gorilla/websocket"
+	"internal/protocol"
 )
 
 var schemeFlag = flag.String("scheme", "ws", "websockt connection scheme")
 var addressFlag = flag.String("server", "127.0.0.1:8080", "Goword server address")
 var lobbyFlag = flag.String("lobby", "bots", "Goword lobby name")
+var sizeFlag = flag.Int("size", 0, "grid size to request when creating the lobby; 0 for the server's default")
+var languageFlag = flag.String("language", "", "language to request when creating the lobby; empty for the server's default")
+var dictionaryFlag = flag.String("dictionary", "", "dictionary to request when creating the lobby")
+var tokenFlag = flag.String("token", "", "session token to sign in with")
 var aggressionFlag = flag.Int("aggression", 20, "aggression constant for word guessing")
 
-func jsonGet(data interface{}, path ...string) interface{} {
-	for _, component := range path {
-		data = (data.(map[string]interface{}))[component]
-	}
-	return data
-}
-
-func joinMessage() []byte {
-	payload, _ := json.Marshal(map[string]string{
-		"command":   "join",
-		"lobbyName": *lobbyFlag,
-	})
-	return payload
-}
-
-func readyMessage() []byte {
-	payload, _ := json.Marshal(map[string]string{
-		"command": "ready",
-	})
-	return payload
-}
-
-func wordMessage(word string) []byte {
-	payload, _ := json.Marshal(map[string]string{
-		"command": "word",
-		"word":    word,
-	})
-	return payload
-}
-
 func main() {
 	rand.Seed(time.Now().Unix())
 
 	flag.Parse()
 
-	u := url.URL{Scheme: *schemeFlag, Host: *addressFlag, Path: "/engine"}
-	log.Fields{"server": u.String()}.Info("connecting to Goword server")
+	if err := language.Load(path.Join("config", "languages")); err != nil {
+		log.Fields{"error": err}.Fatal("couldn't load language packs")
+	}
 
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	address := *schemeFlag + "://" + *addressFlag
+	log.Fields{"server": address, "version": protocol.Version}.Info("connecting to Goword server")
 
+	c, err := protocol.Dial(address, protocol.DialOptions{SessionToken: *tokenFlag})
 	if err != nil {
 		log.Fields{"error": err}.Fatal("couldn't connect to Goword server")
 	}
-
-	defer func() {
-		c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		c.Close()
-	}()
+	defer c.Close()
 
 	wait := make(chan struct{})
 	go signalHandler(wait)
 
-	incomingMessages := make(chan interface{}, 100)
+	done := make(chan struct{})
+	incomingMessages := make(chan protocol.Message, 100)
 	go func() {
-		defer close(wait)
+		defer close(incomingMessages)
 		for {
-			_, message, err := c.ReadMessage()
-			if err != nil {
-				log.Fields{"error": err}.Error("failed to read from websocket client")
-				return
-			}
-
-			var data interface{}
-			if err := json.Unmarshal(message, &data); err != nil {
-				log.Fields{"error": err}.Error("failed to unmarshal JSON payload")
+			message, err := c.Receive()
+			if _, ok := err.(protocol.UnknownMessageError); ok {
+				log.Fields{"error": err}.Debug("ignoring unknown message")
+				continue
+			} else if err != nil {
+				log.Fields{"error": err}.Error("failed to read from Goword server")
 				return
 			}
 
-			incomingMessages <- data
+			incomingMessages <- message
 		}
 	}()
 
 	go func() {
-		nickname := ""
+		id := ""
 		state := ""
 		haveBoard := false
-		var board grid.Grid
 		var solution []string
 
 		heartbeat := time.NewTicker(1 * time.Second)
 
-		defer close(wait)
+		defer close(done)
 		defer heartbeat.Stop()
 
+		send := func(r protocol.Request) bool {
+			if err := c.Send(r); err != nil {
+				log.Fields{"error": err}.Error("failed to send JSON request")
+				return false
+			}
+			return true
+		}
+
 		for {
 			select {
-			case data := <-incomingMessages:
-				if messageType := jsonGet(data, "type"); messageType == "state" {
-					if len(nickname) == 0 {
-						nickname = jsonGet(data, "nickname").(string)
-						log.Fields{"nickname": nickname}.Info("received nickname")
-					}
+			case message, ok := <-incomingMessages:
+				if !ok {
+					return
+				}
 
-					lobby := jsonGet(data, "lobby")
+				switch message := message.(type) {
+				case *protocol.Word:
+					if message.Status != protocol.WordValid {
+						log.Fields{"word": message.Word, "status": message.Status}.Error("server rejected a word from the solution")
+					}
+				case *protocol.Error:
+					log.Fields{"command": message.Command, "message": message.Message}.Error("server reported an error")
+				case *protocol.State:
+					if len(id) == 0 {
+						id = message.ID
+						log.Fields{"id": id, "nickname": message.Nickname}.Info("received player ID")
+					}
 
+					lobby := message.Lobby
 					if lobby == nil {
-						err = c.WriteMessage(websocket.TextMessage, joinMessage())
-						if err != nil {
-							log.Fields{"error": err}.Error("failed to send JSON request")
+						if !send(&protocol.JoinRequest{
+							LobbyName:  *lobbyFlag,
+							Language:   *languageFlag,
+							Size:       *sizeFlag,
+							Dictionary: *dictionaryFlag,
+						}) {
 							return
 						}
-					} else {
-						state = jsonGet(lobby, "state").(string)
-						if state == "inGame" {
-							if !haveBoard {
-								slices := jsonGet(lobby, "grid").([]interface{})
-								for i := 0; i < 4; i++ {
-									slice := slices[i].([]interface{})
-									for j := 0; j < 4; j++ {
-										board[i][j] = slice[j].(string)
-									}
-								}
-								solution = board.Solve()
-								log.Fields{"board": board, "solution": solution}.Info("Received new grid")
+						continue
+					}
+
+					state = lobby.State
+					if state == protocol.StateInGame {
+						if !haveBoard {
+							board := grid.Grid(lobby.Grid)
+							var d *dictionary.Dictionary
+							lang, ok := language.Get(lobby.Language)
+							if ok {
+								d, ok = lang.Dictionary(lobby.Dictionary)
 							}
-						} else if state == "betweenGames" {
-							if readied := jsonGet(lobby, "players", nickname, "readied").(bool); !readied {
-								err = c.WriteMessage(websocket.TextMessage, readyMessage())
-								if err != nil {
-									log.Fields{"error": err}.Error("failed to send JSON request")
-									return
-								}
+							if !ok {
+								log.Fields{"language": lobby.Language, "dictionary": lobby.Dictionary}.Error("lobby uses a dictionary unknown to this bot")
+								return
+							}
+							solution = board.Solve(d)
+							log.Fields{"board": board, "solution": solution}.Info("Received new grid")
+						}
+					} else if state == protocol.StateBetweenGames {
+						if player := lobby.Player(id); player != nil && !player.Readied {
+							if !send(&protocol.ReadyRequest{}) {
+								return
 							}
 						}
-						haveBoard = (state == "inGame")
 					}
+					haveBoard = (state == protocol.StateInGame)
 				}
 			case <-heartbeat.C:
-				if state == "inGame" {
+				if state == protocol.StateInGame && len(solution) > 0 {
 					if rand.Intn(100) < *aggressionFlag {
-						err = c.WriteMessage(websocket.TextMessage, wordMessage(solution[rand.Intn(len(solution))]))
-						if err != nil {
-							log.Fields{"error": err}.Error("failed to send JSON request")
+						// Each word is sent once; resending it would only be
+						// rejected as a duplicate.
+						i := rand.Intn(len(solution))
+						word := solution[i]
+						solution[i] = solution[len(solution)-1]
+						solution = solution[:len(solution)-1]
+
+						if !send(&protocol.WordRequest{Word: word}) {
 							return
 						}
 					}
@@ -162,7 +160,10 @@ func main() {
 		}
 	}()
 
-	<-wait
+	select {
+	case <-wait:
+	case <-done:
+	}
 }
 
 func signalHandler(die chan struct{}) {
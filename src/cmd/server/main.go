@@ -4,7 +4,9 @@ import (
 	"flag"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"internal/log"
 	"internal/server"
@@ -12,6 +14,14 @@ import (
 
 var addressFlag = flag.String("address", ":8080", "address to listen on")
 var debugFlag = flag.Bool("debug", false, "enable debug output")
+var graceFlag = flag.Duration("grace", 30*time.Second, "how long to let games in progress finish when shutting down")
+var historyFlag = flag.String("history", "games.jsonl", "file to record finished games in; empty to keep them in memory only")
+var accountsFlag = flag.String("accounts", "accounts.jsonl", "file to keep player accounts in; empty to keep them in memory only")
+var sessionKeyFlag = flag.String("session-key", "session.key", "file holding the key that signs session tokens; created if missing")
+var challengesFlag = flag.String("challenges", "challenges.jsonl", "file to keep daily challenge attempts in; empty to keep them in memory only")
+var challengeKeyFlag = flag.String("challenge-key", "challenge.key", "file holding the key daily challenge boards are derived from; created if missing")
+var trustedProxiesFlag = flag.String("trusted-proxies", "", "comma-separated addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header to trust")
+var allowedOriginsFlag = flag.String("allowed-origins", "", "comma-separated origins, besides the server's own, whose pages may open websockets")
 
 func main() {
 	flag.Parse()
@@ -20,20 +30,36 @@ func main() {
 		log.EnableDebug()
 	}
 
-	wait := make(chan error)
-	go signalHandler(wait)
-	go func() { wait <- server.Server(*addressFlag) }()
+	stop := make(chan struct{})
+	go signalHandler(stop)
 
-	if err := <-wait; err != nil {
+	config := server.Config{
+		Address:        *addressFlag,
+		Grace:          *graceFlag,
+		HistoryPath:    *historyFlag,
+		AccountsPath:   *accountsFlag,
+		SessionKeyPath: *sessionKeyFlag,
+
+		ChallengesPath:   *challengesFlag,
+		ChallengeKeyPath: *challengeKeyFlag,
+
+		TrustedProxies: strings.Split(*trustedProxiesFlag, ","),
+		AllowedOrigins: strings.Split(*allowedOriginsFlag, ","),
+	}
+	if err := server.Server(config, stop); err != nil {
 		log.Fields{"error": err}.Fatal("unexpected top-level crash")
 	}
 }
 
-func signalHandler(die chan error) {
+func signalHandler(stop chan struct{}) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM)
-	for sig := range c {
-		log.Fields{"signal": sig}.Info("received signal - terminating")
-		close(die)
-	}
+
+	sig := <-c
+	log.Fields{"signal": sig}.Info("received signal - draining; signal again to exit immediately")
+	close(stop)
+
+	sig = <-c
+	log.Fields{"signal": sig}.Info("received second signal - terminating")
+	os.Exit(1)
 }
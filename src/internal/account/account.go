@@ -0,0 +1,110 @@
+// Package account manages optional player accounts: registration, password
+// checks and signed session tokens.
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"internal/log"
+	"internal/nickname"
+)
+
+const (
+	minimumPasswordLength = 8
+
+	// bcrypt only reads the first 72 bytes of a password.
+	maximumPasswordBytes = 72
+)
+
+var (
+	ErrNotFound       = errors.New("no such account")
+	ErrUsernameTaken  = errors.New("That username is already registered")
+	ErrBadCredentials = errors.New("Incorrect username or password")
+)
+
+type Account struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	// SessionGeneration is carried by every session token issued to the
+	// account; moving it on revokes them all.
+	SessionGeneration int `json:"sessionGeneration,omitempty"`
+}
+
+type Store interface {
+	// Add saves a new account, or fails with ErrUsernameTaken if another
+	// account has the same username, ignoring case.
+	Add(account Account) error
+
+	// Update replaces the stored account with the same ID, or fails with
+	// ErrNotFound if there is none. Usernames may not change.
+	Update(account Account) error
+	ByID(id string) (Account, error)
+	ByUsername(username string) (Account, error)
+	Close() error
+}
+
+// Register validates and creates an account. Usernames follow the same rules
+// as nicknames, since an account's username is used as its nickname.
+func Register(s Store, username, password string) (Account, error) {
+	username = nickname.Normalize(username)
+	if err := nickname.Validate(username); err != nil {
+		return Account{}, errors.New(strings.Replace(err.Error(), "Nicknames", "Usernames", 1))
+	}
+
+	if utf8.RuneCountInString(password) < minimumPasswordLength {
+		return Account{}, fmt.Errorf("Passwords must be at least %d characters long", minimumPasswordLength)
+	}
+	if len(password) > maximumPasswordBytes {
+		return Account{}, fmt.Errorf("Passwords may be at most %d bytes long", maximumPasswordBytes)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return Account{}, err
+	}
+
+	account := Account{
+		ID:           newID(),
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	}
+	if err = s.Add(account); err != nil {
+		return Account{}, err
+	}
+	return account, nil
+}
+
+func Login(s Store, username, password string) (Account, error) {
+	account, err := s.ByUsername(nickname.Normalize(username))
+	if err == ErrNotFound {
+		// Hash anyway, so that unknown usernames take as long to reject as
+		// wrong passwords.
+		_, _ = hashPassword(password)
+		return Account{}, ErrBadCredentials
+	} else if err != nil {
+		return Account{}, err
+	}
+
+	if !checkPassword(account.PasswordHash, password) {
+		return Account{}, ErrBadCredentials
+	}
+	return account, nil
+}
+
+func newID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		log.Fields{"error": err}.Panic("couldn't generate account ID")
+	}
+	return hex.EncodeToString(id)
+}
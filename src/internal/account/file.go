@@ -0,0 +1,93 @@
+package account
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// File keeps accounts in memory, backed by a file of JSON lines that new and
+// updated accounts are appended to; the last line for an account wins.
+type File struct {
+	*Memory
+	file *os.File
+}
+
+func OpenFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Memory: NewMemory(),
+		file:   file,
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var account Account
+		if err = json.Unmarshal(scanner.Bytes(), &account); err != nil {
+			file.Close()
+			return nil, err
+		}
+		if _, ok := f.byID[account.ID]; ok {
+			_, err = f.Memory.update(account)
+		} else {
+			err = f.Memory.add(account)
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) Add(account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if err = f.Memory.add(account); err != nil {
+		return err
+	}
+	if _, err = f.file.Write(append(data, '\n')); err != nil {
+		delete(f.byID, account.ID)
+		delete(f.byUsername, lowerUsername(account))
+		return err
+	}
+	return nil
+}
+
+func (f *File) Update(account Account) error {
+	f.Lock()
+	defer f.Unlock()
+
+	previous, err := f.Memory.update(account)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(f.byID[account.ID])
+	if err == nil {
+		_, err = f.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		f.Memory.update(previous)
+		return err
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
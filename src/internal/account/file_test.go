@@ -0,0 +1,46 @@
+package account
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileUpdateSurvivesReopening(t *testing.T) {
+	directory, err := ioutil.TempDir("", "account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "accounts.jsonl")
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Add(Account{ID: "a1", Username: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = Revoke(f, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Update(Account{ID: "missing", Username: "Bob"}); err != ErrNotFound {
+		t.Errorf("updating a missing account returned %v, want ErrNotFound", err)
+	}
+	f.Close()
+
+	f, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	a, err := f.ByUsername("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != "a1" || a.SessionGeneration != 1 {
+		t.Errorf("reopened file holds %+v, want a1 at session generation 1", a)
+	}
+}
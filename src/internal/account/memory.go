@@ -0,0 +1,87 @@
+package account
+
+import (
+	"strings"
+	"sync"
+)
+
+// Memory keeps accounts in memory only, for running without an accounts file.
+type Memory struct {
+	sync.RWMutex
+	byID       map[string]Account
+	byUsername map[string]Account
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		byID:       map[string]Account{},
+		byUsername: map[string]Account{},
+	}
+}
+
+func (m *Memory) Add(account Account) error {
+	m.Lock()
+	defer m.Unlock()
+	return m.add(account)
+}
+
+func (m *Memory) add(account Account) error {
+	key := lowerUsername(account)
+	if _, ok := m.byUsername[key]; ok {
+		return ErrUsernameTaken
+	}
+
+	m.byID[account.ID] = account
+	m.byUsername[key] = account
+	return nil
+}
+
+func (m *Memory) Update(account Account) error {
+	m.Lock()
+	defer m.Unlock()
+	_, err := m.update(account)
+	return err
+}
+
+// update replaces an account, returning the one it replaced.
+func (m *Memory) update(account Account) (Account, error) {
+	previous, ok := m.byID[account.ID]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+
+	account.Username = previous.Username
+	m.byID[account.ID] = account
+	m.byUsername[lowerUsername(account)] = account
+	return previous, nil
+}
+
+func (m *Memory) ByID(id string) (Account, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	account, ok := m.byID[id]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+func (m *Memory) ByUsername(username string) (Account, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	account, ok := m.byUsername[strings.ToLower(username)]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func lowerUsername(account Account) string {
+	return strings.ToLower(account.Username)
+}
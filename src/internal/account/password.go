@@ -0,0 +1,19 @@
+package account
+
+import "golang.org/x/crypto/bcrypt"
+
+// passwordCost is the bcrypt work factor. Each hash records its own cost, so
+// raising it later doesn't invalidate existing hashes.
+const passwordCost = 12
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func checkPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
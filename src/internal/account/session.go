@@ -0,0 +1,90 @@
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"internal/secret"
+)
+
+const sessionKeyLength = 32
+
+// Sessions issues and verifies tokens of the form
+// "id.generation.expiry.signature", signed with HMAC-SHA256 under a
+// server-side key.
+type Sessions struct {
+	key      []byte
+	lifetime time.Duration
+}
+
+// LoadSessions reads the signing key from path, generating and saving a new
+// one if the file doesn't exist. With an empty path the key lives only as
+// long as the process, so sessions don't survive a restart.
+func LoadSessions(path string, lifetime time.Duration) (*Sessions, error) {
+	key, err := secret.Load(path, sessionKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	return &Sessions{key: key, lifetime: lifetime}, nil
+}
+
+func (s *Sessions) Lifetime() time.Duration {
+	return s.lifetime
+}
+
+func (s *Sessions) Issue(a Account) string {
+	payload := a.ID + "." + strconv.Itoa(a.SessionGeneration) + "." + strconv.FormatInt(time.Now().Add(s.lifetime).Unix(), 10)
+	return payload + "." + s.sign(payload)
+}
+
+// Verify returns the account ID and session generation a token was issued
+// for, if the token is genuine and unexpired. The caller checks that the
+// generation is still the account's current one.
+func (s *Sessions) Verify(token string) (string, int, bool) {
+	split := strings.LastIndex(token, ".")
+	if split < 0 {
+		return "", 0, false
+	}
+
+	payload, signature := token[:split], token[split+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", 0, false
+	}
+
+	fields := strings.Split(payload, ".")
+	if len(fields) != 3 {
+		return "", 0, false
+	}
+
+	generation, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, false
+	}
+
+	expiry, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return "", 0, false
+	}
+	return fields[0], generation, true
+}
+
+// Revoke ends every session issued to an account so far.
+func Revoke(store Store, id string) error {
+	a, err := store.ByID(id)
+	if err != nil {
+		return err
+	}
+
+	a.SessionGeneration++
+	return store.Update(a)
+}
+
+func (s *Sessions) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,146 @@
+// Package challenge runs the daily challenge: one board a day, which every
+// signed-in player may play once, on their own.
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"internal/secret"
+	"internal/store"
+)
+
+const (
+	dayFormat = "2006-01-02"
+	keyLength = 32
+)
+
+var ErrAlreadyPlayed = errors.New("You have already played today's challenge; come back tomorrow")
+
+// Result is an account's attempt at a day's challenge. An attempt is recorded
+// when it starts, so that leaving early does not earn another try; Finished
+// is set once its game has been scored.
+type Result struct {
+	Day       string       `json:"day"`
+	AccountID string       `json:"accountId"`
+	StartedAt time.Time    `json:"startedAt"`
+	Finished  bool         `json:"finished"`
+	Score     int          `json:"score"`
+	Words     []store.Word `json:"words,omitempty"`
+}
+
+type Store interface {
+	// Start records that an account has begun a day's challenge, or fails
+	// with ErrAlreadyPlayed if it already has.
+	Start(day, accountID string, at time.Time) error
+	Finish(result Result) error
+
+	// Results returns the finished attempts at a day's challenge.
+	Results(day string) ([]Result, error)
+
+	Close() error
+}
+
+// Daily derives each day's board from a secret key, so that it cannot be
+// worked out, or looked up, ahead of time.
+type Daily struct {
+	Store
+	key []byte
+}
+
+func New(key []byte, s Store) *Daily {
+	return &Daily{
+		Store: s,
+		key:   key,
+	}
+}
+
+// LoadKey reads the key days' seeds are derived from, generating it and
+// saving it to path if there is none yet. A key file too short to use is an
+// error, since a new key would change the day's board. With an empty path the
+// key lasts only as long as the process.
+func LoadKey(path string) ([]byte, error) {
+	return secret.Load(path, keyLength)
+}
+
+// Today returns the current day, in UTC.
+func Today() string {
+	return time.Now().UTC().Format(dayFormat)
+}
+
+// ValidDay reports whether day is formatted as Today formats days.
+func ValidDay(day string) bool {
+	_, err := time.Parse(dayFormat, day)
+	return err == nil
+}
+
+// Seed returns the grid seed for a day.
+func (d *Daily) Seed(day string) int64 {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write([]byte(day))
+	return int64(binary.BigEndian.Uint64(mac.Sum(nil)) & math.MaxInt64)
+}
+
+type Entry struct {
+	Rank      int    `json:"rank"`
+	AccountID string `json:"accountId"`
+	Score     int    `json:"score"`
+	Words     int    `json:"words"`
+}
+
+// Leaderboard ranks finished attempts by score, returning at most limit
+// entries. Attempts with equal scores share a rank, earliest first.
+func Leaderboard(results []Result, limit int) []Entry {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
+		}
+		return sorted[i].StartedAt.Before(sorted[j].StartedAt)
+	})
+
+	entries := make([]Entry, len(sorted))
+	for i, result := range sorted {
+		entries[i] = Entry{
+			Rank:      i + 1,
+			AccountID: result.AccountID,
+			Score:     result.Score,
+			Words:     len(result.Words),
+		}
+		if i > 0 && sorted[i-1].Score == result.Score {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// Standing returns the rank of a score among finished attempts, and its
+// percentile: the share of attempts it beat, counting ties as half.
+func Standing(results []Result, score int) (int, float64) {
+	if len(results) == 0 {
+		return 1, 100
+	}
+
+	above, below, equal := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Score > score:
+			above++
+		case result.Score < score:
+			below++
+		default:
+			equal++
+		}
+	}
+	return above + 1, 100 * (float64(below) + float64(equal)/2) / float64(len(results))
+}
@@ -0,0 +1,83 @@
+package challenge
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// File keeps attempts in memory, backed by a file of JSON lines that each
+// attempt is appended to when it starts and again when it finishes; the last
+// line for an attempt wins.
+type File struct {
+	*Memory
+	file *os.File
+}
+
+func OpenFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Memory: NewMemory(),
+		file:   file,
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var result Result
+		if err = json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			file.Close()
+			return nil, err
+		}
+		f.Memory.put(result)
+	}
+	if err = scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) Start(day, accountID string, at time.Time) error {
+	result := Result{Day: day, AccountID: accountID, StartedAt: at}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if err = f.Memory.start(result); err != nil {
+		return err
+	}
+	if _, err = f.file.Write(append(data, '\n')); err != nil {
+		delete(f.days[day], accountID)
+		return err
+	}
+	return nil
+}
+
+func (f *File) Finish(result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if _, err = f.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	f.Memory.put(result)
+	return nil
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,65 @@
+package challenge
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory keeps attempts in memory only, for running without a challenge file.
+type Memory struct {
+	sync.RWMutex
+	days map[string]map[string]Result
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		days: map[string]map[string]Result{},
+	}
+}
+
+func (m *Memory) Start(day, accountID string, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	return m.start(Result{Day: day, AccountID: accountID, StartedAt: at})
+}
+
+func (m *Memory) start(result Result) error {
+	if _, ok := m.days[result.Day][result.AccountID]; ok {
+		return ErrAlreadyPlayed
+	}
+	m.put(result)
+	return nil
+}
+
+func (m *Memory) put(result Result) {
+	attempts, ok := m.days[result.Day]
+	if !ok {
+		attempts = map[string]Result{}
+		m.days[result.Day] = attempts
+	}
+	attempts[result.AccountID] = result
+}
+
+func (m *Memory) Finish(result Result) error {
+	m.Lock()
+	defer m.Unlock()
+	m.put(result)
+	return nil
+}
+
+func (m *Memory) Results(day string) ([]Result, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	results := []Result{}
+	for _, result := range m.days[day] {
+		if result.Finished {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
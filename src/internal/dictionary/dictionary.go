@@ -0,0 +1,33 @@
+package dictionary
+
+import (
+	"encoding/json"
+
+	"internal/wordlist"
+)
+
+const Default = "english"
+
+type Dictionary struct {
+	Name     string
+	Language string
+	fold     func(string) string
+	*wordlist.Trie
+}
+
+func New(name, language string, list wordlist.Wordlist, fold func(string) string) *Dictionary {
+	return &Dictionary{
+		Name:     name,
+		Language: language,
+		fold:     fold,
+		Trie:     list.Trie(),
+	}
+}
+
+func (d *Dictionary) Fold(word string) string {
+	return d.fold(word)
+}
+
+func (d *Dictionary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Name)
+}
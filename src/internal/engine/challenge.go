@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"time"
+
+	"internal/challenge"
+	"internal/grid"
+	"internal/language"
+	"internal/log"
+	"internal/protocol"
+	"internal/store"
+)
+
+const (
+	challengeGameDuration      = 3 * time.Minute
+	challengeCountdownDuration = 5 * time.Second
+)
+
+// challengeRun is an account's attempt at the daily challenge, played in a
+// lobby of its own. Its standing is filled in once the game has been scored.
+type challengeRun struct {
+	Day        string   `json:"day"`
+	Finished   bool     `json:"finished"`
+	Rank       int      `json:"rank,omitempty"`
+	Percentile *float64 `json:"percentile,omitempty"`
+	Players    int      `json:"players,omitempty"`
+
+	daily   *challenge.Daily
+	seed    int64
+	started bool
+}
+
+// SetChallenge offers the daily challenge in d to signed-in clients.
+func (e *Engine) SetChallenge(d *challenge.Daily) {
+	e.daily = d
+}
+
+// Challenge starts the client's attempt at today's challenge.
+func (c *Client) Challenge() {
+	c.incomingPipe <- incomingMessage{
+		what:   messageTypeChallenge,
+		client: c,
+	}
+}
+
+func engineHandleChallenge(e *Engine, client *Client, _ interface{}) {
+	if e.daily == nil {
+		client.Send(clientErrorMessage{
+			Command: "challenge",
+			Code:    protocol.ErrorNotFound,
+			Message: "This server does not run a daily challenge",
+		})
+		return
+	}
+
+	if client.AccountID == "" {
+		client.Send(clientErrorMessage{
+			Command: "challenge",
+			Code:    protocol.ErrorForbidden,
+			Message: "Sign in to play the daily challenge",
+		})
+		return
+	}
+
+	if e.draining {
+		client.Send(clientErrorMessage{
+			Command: "challenge",
+			Code:    protocol.ErrorShuttingDown,
+			Message: "The server is shutting down; try the daily challenge again shortly",
+		})
+		return
+	}
+
+	day := challenge.Today()
+	if err := e.daily.Start(day, client.AccountID, time.Now()); err == challenge.ErrAlreadyPlayed {
+		client.Send(clientErrorMessage{
+			Command: "challenge",
+			Code:    protocol.ErrorForbidden,
+			Message: err.Error(),
+		})
+		return
+	} else if err != nil {
+		log.Fields{"client": client.Nickname, "error": err}.Error("couldn't record start of daily challenge")
+		client.Send(clientErrorMessage{
+			Command: "challenge",
+			Code:    protocol.ErrorInternal,
+			Message: "Couldn't start the daily challenge; please try again",
+		})
+		return
+	}
+
+	lang, _ := language.Get(language.Default)
+	cubes, _ := lang.Cubes(grid.SizeStandard)
+	d, _ := lang.Dictionary("")
+
+	settings := defaultLobbySettings()
+	settings.GameDuration = challengeGameDuration
+	settings.CountdownDuration = challengeCountdownDuration
+	settings.MinimumPlayers = 1
+
+	lobby := e.newLobby("daily-"+day, lang, cubes, d, settings, nil, false)
+	lobby.Challenge = &challengeRun{
+		Day:   day,
+		daily: e.daily,
+		seed:  e.daily.Seed(day),
+	}
+
+	// Challenge lobbies are keyed by a name no client may join or spectate.
+	normalizedName := "daily:" + client.ID
+	e.lobbies[normalizedName] = lobby
+	go lobby.run()
+
+	e.enterLobby(client, normalizedName, lobby, false)
+	log.Fields{"client": client.Nickname, "day": day}.Info("client started the daily challenge")
+}
+
+func lobbyHandleChallenge(l *lobby, client *Client, _ interface{}) {
+	client.Send(clientErrorMessage{
+		Command: "challenge",
+		Code:    protocol.ErrorAlreadyInLobby,
+		Message: "Leave the lobby to play the daily challenge",
+	})
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to start the daily challenge, but is in a lobby")
+}
+
+// finishChallenge records the outcome of a daily challenge and works out where
+// it stands among the day's other attempts.
+func (l *lobby) finishChallenge(clients []*Client, wordlists [][]string, totals []int, scores [][]int) {
+	l.Challenge.Finished = true
+	if len(clients) == 0 {
+		return
+	}
+
+	client := clients[0]
+	result := challenge.Result{
+		Day:       l.Challenge.Day,
+		AccountID: client.AccountID,
+		StartedAt: l.startedAt,
+		Finished:  true,
+		Score:     totals[0],
+		Words:     make([]store.Word, len(wordlists[0])),
+	}
+	for i, word := range wordlists[0] {
+		result.Words[i] = store.Word{
+			Word:   word,
+			Points: scores[0][i],
+		}
+	}
+
+	if err := l.Challenge.daily.Finish(result); err != nil {
+		log.Fields{"lobby": l.Name, "client": client.Nickname, "error": err}.Error("couldn't record daily challenge result")
+	}
+
+	results, err := l.Challenge.daily.Results(l.Challenge.Day)
+	if err != nil {
+		log.Fields{"lobby": l.Name, "error": err}.Error("couldn't read daily challenge results")
+		return
+	}
+
+	others := make([]challenge.Result, 0, len(results))
+	for _, other := range results {
+		if other.AccountID != client.AccountID {
+			others = append(others, other)
+		}
+	}
+
+	rank, percentile := challenge.Standing(others, result.Score)
+	l.Challenge.Rank = rank
+	l.Challenge.Percentile = &percentile
+	l.Challenge.Players = len(others) + 1
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"internal/log"
+	"internal/protocol"
+	"internal/ratelimit"
+)
+
+const (
+	chatMaxLength = 280
+	chatRate      = 0.5
+	chatBurst     = 5
+)
+
+type ChatMessage struct {
+	Lobby  string
+	State  string
+	Sender string
+	Text   string
+}
+
+// ChatFilter inspects every chat message before it is broadcast. A filter may
+// rewrite message.Text, or return an error to refuse the message; the error
+// text is shown to the sender.
+type ChatFilter interface {
+	FilterChat(message *ChatMessage) error
+}
+
+type QuietDuringGames struct{}
+
+func (QuietDuringGames) FilterChat(message *ChatMessage) error {
+	if message.State == protocol.StateInGame {
+		return errors.New("Chat is disabled while a game is in progress")
+	}
+	return nil
+}
+
+type Blocklist map[string]struct{}
+
+func NewBlocklist(words []string) Blocklist {
+	b := Blocklist{}
+	for _, word := range words {
+		if word = strings.TrimSpace(strings.ToLower(word)); word != "" {
+			b[word] = struct{}{}
+		}
+	}
+	return b
+}
+
+// LoadBlocklist reads a blocklist from a file with one word per line.
+func LoadBlocklist(path string) (Blocklist, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlocklist(strings.Split(string(blob), "\n")), nil
+}
+
+func (b Blocklist) FilterChat(message *ChatMessage) error {
+	words := strings.Fields(message.Text)
+	for i, word := range words {
+		if _, ok := b[strings.ToLower(strings.Trim(word, ".,!?;:'\"()"))]; ok {
+			words[i] = strings.Repeat("*", utf8.RuneCountInString(word))
+		}
+	}
+	message.Text = strings.Join(words, " ")
+	return nil
+}
+
+func (e *Engine) AddChatFilter(filter ChatFilter) {
+	e.chatFilters = append(e.chatFilters, filter)
+}
+
+func (l *lobby) filterChat(message *ChatMessage) error {
+	message.Text = strings.TrimSpace(message.Text)
+
+	if message.Text == "" {
+		return errors.New("Chat messages may not be empty")
+	}
+
+	if utf8.RuneCountInString(message.Text) > chatMaxLength {
+		return fmt.Errorf("Chat messages may be at most %d characters", chatMaxLength)
+	}
+
+	for _, filter := range l.chatFilters {
+		if err := filter.FilterChat(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lobbyHandleChat(l *lobby, client *Client, data interface{}) {
+	if client.chatLimiter == nil {
+		client.chatLimiter = ratelimit.New(chatRate, chatBurst)
+	}
+
+	if !client.chatLimiter.Allow() {
+		client.Send(clientErrorMessage{
+			Command: "chat",
+			Code:    protocol.ErrorRateLimited,
+			Message: "You are sending messages too quickly",
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client was rate limited in chat")
+		return
+	}
+
+	message := ChatMessage{
+		Lobby:  l.Name,
+		State:  l.State,
+		Sender: client.Nickname,
+		Text:   data.(string),
+	}
+
+	if err := l.filterChat(&message); err != nil {
+		client.Send(clientErrorMessage{
+			Command: "chat",
+			Code:    protocol.ErrorRejected,
+			Message: err.Error(),
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname, "error": err}.Debug("client chat message was refused")
+		return
+	}
+
+	_, spectator := l.Spectators[client]
+	l.broadcast(clientChatMessage{
+		FromID:    client.ID,
+		From:      client.Nickname,
+		Text:      message.Text,
+		Spectator: spectator,
+	})
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client chatted")
+}
+
+func engineHandleChat(e *Engine, client *Client, _ interface{}) {
+	client.Send(clientErrorMessage{
+		Command: "chat",
+		Code:    protocol.ErrorNotInLobby,
+		Message: "You are not in a lobby",
+	})
+
+	log.Fields{"client": client.Nickname}.Debug("client attempted to chat, but was not in a lobby")
+}
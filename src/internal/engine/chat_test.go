@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"path"
+	"testing"
+
+	"internal/protocol"
+)
+
+// chat sends text from client to everyone in its lobby, returning what each
+// recipient received.
+func chat(t *testing.T, l *lobby, client *Client, text string) map[*Client][]protocol.Message {
+	lobbyHandleChat(l, client, text)
+
+	messages := map[*Client][]protocol.Message{}
+	for member := range l.Clients {
+		messages[member] = received(t, member)
+	}
+	return messages
+}
+
+func TestChatBlocklist(t *testing.T) {
+	blocklist, err := LoadBlocklist(path.Join(configDirectory, "chat-blocklist.list"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	e.AddChatFilter(QuietDuringGames{})
+	e.AddChatFilter(blocklist)
+	l := newTestLobby(e, "chat")
+
+	alice := newTestClient("a1", "Alice")
+	alice.Lobby = l
+	l.Clients[alice] = &clientData{}
+	bob := newTestClient("b2", "Bob")
+	bob.Lobby = l
+	l.Clients[bob] = &clientData{}
+
+	messages := chat(t, l, alice, "well SHIT that was close")
+	got, ok := messages[bob][0].(*protocol.Chat)
+	if len(messages[bob]) != 1 || !ok {
+		t.Fatalf("Bob received %v, want one chat message", messages[bob])
+	}
+	if got.Text != "well **** that was close" {
+		t.Errorf("Bob received %q, want the blocked word masked", got.Text)
+	}
+
+	// Filters that refuse a message keep it from everyone but the sender,
+	// who is told why.
+	l.State = protocol.StateInGame
+	messages = chat(t, l, alice, "try TEAS")
+	if len(messages[bob]) != 0 {
+		t.Errorf("Bob received %v during a game", messages[bob])
+	}
+	refusal, ok := messages[alice][0].(*protocol.Error)
+	if len(messages[alice]) != 1 || !ok || refusal.Code != protocol.ErrorRejected {
+		t.Errorf("Alice received %v, want a rejection", messages[alice])
+	}
+}
@@ -1,17 +1,45 @@
 package engine
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"internal/account"
+	"internal/log"
+	"internal/ratelimit"
+)
+
 type Client struct {
 	incomingPipe chan incomingMessage
-	OutgoingPipe chan OutgoingMessage `json:"-"`
+	outbox       *outbox
+
+	ID        string `json:"id"`
+	AccountID string `json:"accountId,omitempty"`
+	Nickname  string `json:"nickname"`
+	Rating    int    `json:"rating,omitempty"`
+	Lobby     *lobby `json:"lobby,omitempty"`
 
-	Nickname string `json:"nickname"`
-	Lobby    *lobby `json:"lobby,omitempty"`
+	Spectator bool `json:"spectator,omitempty"`
+
+	username    string
+	resumeToken string
+	detached    bool
+	quit        bool
+	chatLimiter *ratelimit.Bucket
+	replayStop  chan struct{}
 }
 
-func (e *Engine) NewClient() *Client {
+// NewClient connects a new client to the engine; a is the account it signed
+// in with, or nil if it is playing anonymously.
+func (e *Engine) NewClient(a *account.Account) *Client {
 	client := &Client{
+		ID:           randomHex(8),
 		incomingPipe: e.incomingPipe,
-		OutgoingPipe: newOutgoingPipe(),
+		outbox:       newOutbox(),
+	}
+	if a != nil {
+		client.AccountID = a.ID
+		client.username = a.Username
 	}
 
 	client.incomingPipe <- incomingMessage{
@@ -22,6 +50,30 @@ func (e *Engine) NewClient() *Client {
 	return client
 }
 
+func (e *Engine) Resume(token string) *Client {
+	reply := make(chan *Client, 1)
+	e.incomingPipe <- incomingMessage{
+		what: messageTypeResume,
+		payload: resumeRequest{
+			token: token,
+			reply: reply,
+		},
+	}
+	return <-reply
+}
+
+func newResumeToken() string {
+	return randomHex(16)
+}
+
+func randomHex(length int) string {
+	buffer := make([]byte, length)
+	if _, err := rand.Read(buffer); err != nil {
+		log.Fields{"error": err}.Panic("couldn't read random bytes")
+	}
+	return hex.EncodeToString(buffer)
+}
+
 func (c *Client) Quit() {
 	c.incomingPipe <- incomingMessage{
 		what:   messageTypeQuit,
@@ -29,11 +81,35 @@ func (c *Client) Quit() {
 	}
 }
 
-func (c *Client) Join(lobbyName string) {
+type JoinOptions struct {
+	Language   string
+	Size       int
+	Dictionary string
+	Settings   SettingsRequest
+	Passphrase string
+
+	// Rated lobbies admit only signed-in players.
+	Rated bool
+}
+
+// Detach marks the client as disconnected without giving up its nickname or
+// lobby membership; it may be recovered with Engine.Resume until the grace
+// period expires.
+func (c *Client) Detach() {
 	c.incomingPipe <- incomingMessage{
-		what:    messageTypeJoin,
-		client:  c,
-		payload: lobbyName,
+		what:   messageTypeDetach,
+		client: c,
+	}
+}
+
+func (c *Client) Join(lobbyName string, options JoinOptions) {
+	c.incomingPipe <- incomingMessage{
+		what:   messageTypeJoin,
+		client: c,
+		payload: joinPayload{
+			lobbyName: lobbyName,
+			options:   options,
+		},
 	}
 }
 
@@ -58,3 +134,39 @@ func (c *Client) Word(word string) {
 		payload: word,
 	}
 }
+
+func (c *Client) Configure(settings SettingsRequest) {
+	c.incomingPipe <- incomingMessage{
+		what:    messageTypeConfigure,
+		client:  c,
+		payload: settings,
+	}
+}
+
+func (c *Client) List() {
+	c.incomingPipe <- incomingMessage{
+		what:   messageTypeList,
+		client: c,
+	}
+}
+
+func (c *Client) Spectate(lobbyName, passphrase string) {
+	c.incomingPipe <- incomingMessage{
+		what:   messageTypeSpectate,
+		client: c,
+		payload: joinPayload{
+			lobbyName: lobbyName,
+			options: JoinOptions{
+				Passphrase: passphrase,
+			},
+		},
+	}
+}
+
+func (c *Client) Chat(text string) {
+	c.incomingPipe <- incomingMessage{
+		what:    messageTypeChat,
+		client:  c,
+		payload: text,
+	}
+}
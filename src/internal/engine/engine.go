@@ -2,16 +2,25 @@ package engine
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"internal/account"
+	"internal/challenge"
+	"internal/grid"
+	"internal/language"
 	"internal/log"
 	"internal/nickname"
+	"internal/protocol"
+	"internal/rating"
+	"internal/store"
 )
 
 const (
 	engineHeartbeatInterval = time.Second * 5
 	lobbyTimeToLive         = time.Second * 30
+	resumeGracePeriod       = time.Second * 90
 )
 
 var lobbyNameRegex = regexp.MustCompile("^[\\w-]+$")
@@ -24,6 +33,24 @@ type Engine struct {
 
 	lobbies  map[string]*lobby
 	joinedAt map[string]time.Time
+
+	detached map[string]detachedClient
+
+	chatFilters []ChatFilter
+	history     chan store.Game
+	historyStop chan struct{}
+	historyDone chan struct{}
+	store       store.Store
+	accounts    account.Store
+	ratings     *rating.Table
+	daily       *challenge.Daily
+
+	draining bool
+}
+
+type detachedClient struct {
+	client *Client
+	since  time.Time
 }
 
 var engineDispatchTable = [messageTypeCount]func(*Engine, *Client, interface{}){
@@ -33,14 +60,26 @@ var engineDispatchTable = [messageTypeCount]func(*Engine, *Client, interface{}){
 	engineHandlePart,
 	engineHandleReady,
 	engineHandleWord,
+	engineHandleConfigure,
+	engineHandleList,
+	engineHandleDetach,
+	engineHandleResume,
+	engineHandleSpectate,
+	engineHandleChat,
+	engineHandleNick,
+	engineHandleReplay,
+	engineHandleChallenge,
+	engineHandleShutdown,
 }
 
 func New() *Engine {
 	return &Engine{
 		incomingPipe:      newIncomingPipe(),
+		terminator:        make(chan struct{}),
 		nicknameGenerator: nickname.Generator{},
 		lobbies:           map[string]*lobby{},
 		joinedAt:          map[string]time.Time{},
+		detached:          map[string]detachedClient{},
 	}
 }
 
@@ -54,10 +93,14 @@ func (e *Engine) Run() {
 			for _, lobby := range e.lobbies {
 				lobby.terminate()
 			}
+			return
 		case message := <-e.incomingPipe:
 			engineDispatchTable[message.what](e, message.client, message.payload)
 		case <-heartbeat:
-			e.garbageCollectLobbies()
+			if !e.draining {
+				e.garbageCollectLobbies()
+			}
+			e.expireDetachedClients()
 		}
 	}
 }
@@ -79,77 +122,356 @@ func (e *Engine) garbageCollectLobbies() {
 	}
 }
 
+func (e *Engine) expireDetachedClients() {
+	for token, detached := range e.detached {
+		if delta := time.Since(detached.since); delta > resumeGracePeriod {
+			log.Fields{"client": detached.client.Nickname, "delta": delta}.Info("detached client did not resume in time; quitting")
+			delete(e.detached, token)
+			detached.client.Quit()
+		}
+	}
+}
+
 func engineHandleNew(e *Engine, client *Client, _ interface{}) {
-	client.Nickname = e.nicknameGenerator.Generate()
-	client.OutgoingPipe <- client.StateMessage("Welcome to Goword; you are known as " + client.Nickname)
+	if client.username != "" && e.nicknameGenerator.Reserve(client.username) {
+		client.Nickname = client.username
+	} else {
+		client.Nickname = e.nicknameGenerator.Generate()
+	}
+	if e.ratings != nil && client.AccountID != "" {
+		client.Rating = e.ratings.Get(client.AccountID).Rating
+	}
+	client.resumeToken = newResumeToken()
+
+	message := client.StateMessage("Welcome to Goword; you are known as " + client.Nickname)
+	message.ResumeToken = client.resumeToken
+	client.Send(message)
 	log.Fields{"client": client.Nickname}.Debug("new client connected to engine")
 }
 
 func engineHandleQuit(e *Engine, client *Client, _ interface{}) {
+	stopReplay(client)
 	e.nicknameGenerator.Free(client.Nickname)
-	close(client.OutgoingPipe)
+	client.quit = true
+	client.outbox.close()
 	log.Fields{"client": client.Nickname}.Debug("client quit engine")
 }
 
 func engineHandleJoin(e *Engine, client *Client, data interface{}) {
-	lobbyName := data.(string)
+	payload := data.(joinPayload)
+	lobbyName := payload.lobbyName
 
 	if !lobbyNameRegex.MatchString(lobbyName) {
-		client.OutgoingPipe <- clientErrorMessage{
+		client.Send(clientErrorMessage{
 			Command: "join",
+			Code:    protocol.ErrorBadArgument,
 			Message: "Lobby name may contain only letters, numbers, dashes, and underscores, and may not be empty",
-		}
+		})
 		return
 	}
 
 	normalizedName := strings.ToLower(lobbyName)
 
-	var lobby *lobby
-	var ok bool
+	lobby, ok := e.lobbies[normalizedName]
+	if !ok {
+		if e.draining {
+			client.Send(clientErrorMessage{
+				Command: "join",
+				Code:    protocol.ErrorShuttingDown,
+				Message: "The server is shutting down; no new lobbies may be created",
+			})
+			return
+		}
 
-	if lobby, ok = e.lobbies[normalizedName]; !ok {
-		log.Fields{"client": client.Nickname, "lobby": lobbyName}.Info("instantiating new lobby")
-		lobby = e.newLobby(lobbyName)
+		if lobby = e.createLobby(client, lobbyName, payload.options); lobby == nil {
+			return
+		}
 		e.lobbies[normalizedName] = lobby
 		go lobby.run()
+	} else if !admit(client, "join", lobby, payload.options.Passphrase) || !admitRated(client, lobby) || !admitOptions(client, lobby, payload.options) {
+		return
+	}
+
+	e.enterLobby(client, normalizedName, lobby, false)
+	log.Fields{"client": client.Nickname, "lobby": lobbyName}.Debug("client joining lobby")
+}
+
+func admit(client *Client, command string, lobby *lobby, passphrase string) bool {
+	if lobby.Challenge != nil {
+		client.Send(clientErrorMessage{
+			Command: command,
+			Code:    protocol.ErrorForbidden,
+			Message: "Daily challenges are played alone",
+		})
+		return false
+	}
+
+	if lobby.passphrase == nil || lobby.passphrase.matches(passphrase) {
+		return true
+	}
+
+	client.Send(clientErrorMessage{
+		Command: command,
+		Code:    protocol.ErrorForbidden,
+		Message: "That lobby is private; you need the correct passphrase to " + command,
+	})
+
+	log.Fields{"client": client.Nickname, "lobby": lobby.Name}.Debug("client tried to enter a private lobby with the wrong passphrase")
+	return false
+}
+
+// admitOptions refuses a join for an existing lobby whose creation options
+// differ from those requested; they apply only when a lobby is created, and
+// settings may be changed afterwards with configure.
+func admitOptions(client *Client, lobby *lobby, options JoinOptions) bool {
+	summary := lobby.loadSummary()
+	differences := []string{}
+
+	if options.Language != "" && options.Language != summary.Language {
+		differences = append(differences, "language")
+	}
+	if options.Size != 0 && options.Size != summary.Size {
+		differences = append(differences, "size")
+	}
+	if options.Dictionary != "" && options.Dictionary != summary.Dictionary {
+		differences = append(differences, "dictionary")
+	}
+	if settings, err := summary.Settings.apply(options.Settings); err != nil || settings != summary.Settings {
+		differences = append(differences, "settings")
+	}
+	if options.Rated && !summary.Rated {
+		differences = append(differences, "rating")
 	}
 
+	if len(differences) == 0 {
+		return true
+	}
+
+	client.Send(clientErrorMessage{
+		Command: "join",
+		Code:    protocol.ErrorLobbyExists,
+		Message: "That lobby already exists with different options (" + strings.Join(differences, ", ") + "); join without them to play it as it is, or pick another name",
+	})
+
+	log.Fields{"client": client.Nickname, "lobby": lobby.Name, "differences": differences}.Debug("client tried to join an existing lobby with other options")
+	return false
+}
+
+func (e *Engine) enterLobby(client *Client, normalizedName string, lobby *lobby, spectator bool) {
 	e.joinedAt[normalizedName] = time.Now()
+	stopReplay(client)
 
 	client.incomingPipe = lobby.incomingPipe
 	client.Lobby = lobby
 
 	client.incomingPipe <- incomingMessage{
-		what:   messageTypeNew,
-		client: client,
+		what:    messageTypeNew,
+		client:  client,
+		payload: spectator,
 	}
+}
 
-	log.Fields{"client": client.Nickname, "lobby": lobbyName}.Debug("client joining lobby")
+func (e *Engine) createLobby(client *Client, lobbyName string, options JoinOptions) *lobby {
+	languageCode := options.Language
+	if languageCode == "" {
+		languageCode = language.Default
+	}
+
+	lang, ok := language.Get(languageCode)
+	if !ok {
+		client.Send(clientErrorMessage{
+			Command: "join",
+			Code:    protocol.ErrorBadArgument,
+			Message: "Language must be one of " + strings.Join(language.Codes(), ", "),
+		})
+		return nil
+	}
+
+	size := options.Size
+	if size == 0 {
+		size = grid.SizeStandard
+	}
+
+	cubes, ok := lang.Cubes(size)
+	if !ok {
+		sizes := []string{}
+		for _, size := range lang.Sizes() {
+			sizes = append(sizes, strconv.Itoa(size))
+		}
+
+		client.Send(clientErrorMessage{
+			Command: "join",
+			Code:    protocol.ErrorBadArgument,
+			Message: lang.Name + " grid size must be one of " + strings.Join(sizes, ", "),
+		})
+		return nil
+	}
+
+	d, ok := lang.Dictionary(options.Dictionary)
+	if !ok {
+		client.Send(clientErrorMessage{
+			Command: "join",
+			Code:    protocol.ErrorBadArgument,
+			Message: lang.Name + " dictionary must be one of " + strings.Join(lang.DictionaryNames(), ", "),
+		})
+		return nil
+	}
+
+	settings, err := defaultLobbySettings().apply(options.Settings)
+	if err != nil {
+		client.Send(clientErrorMessage{
+			Command: "join",
+			Code:    protocol.ErrorBadArgument,
+			Message: err.Error(),
+		})
+		return nil
+	}
+
+	if options.Rated && client.AccountID == "" {
+		client.Send(clientErrorMessage{
+			Command: "join",
+			Code:    protocol.ErrorForbidden,
+			Message: "Sign in to create a rated lobby",
+		})
+		return nil
+	}
+
+	var p *passphrase
+	if options.Passphrase != "" {
+		if p, err = newPassphrase(options.Passphrase); err != nil {
+			log.Fields{"error": err}.Error("couldn't salt lobby passphrase")
+			client.Send(clientErrorMessage{
+				Command: "join",
+				Code:    protocol.ErrorInternal,
+				Message: "Couldn't create a private lobby; please try again",
+			})
+			return nil
+		}
+	}
+
+	log.Fields{"client": client.Nickname, "lobby": lobbyName, "language": lang.Code, "size": size, "dictionary": d.Name, "private": p != nil, "rated": options.Rated}.Info("instantiating new lobby")
+	return e.newLobby(lobbyName, lang, cubes, d, settings, p, options.Rated)
 }
 
 func engineHandlePart(e *Engine, client *Client, _ interface{}) {
-	client.OutgoingPipe <- clientErrorMessage{
+	client.Send(clientErrorMessage{
 		Command: "part",
+		Code:    protocol.ErrorNotInLobby,
 		Message: "You are not in a lobby",
-	}
+	})
 
 	log.Fields{"client": client.Nickname}.Debug("client attempted to part lobby, but was not in a lobby")
 }
 
 func engineHandleReady(e *Engine, client *Client, _ interface{}) {
-	client.OutgoingPipe <- clientErrorMessage{
+	client.Send(clientErrorMessage{
 		Command: "ready",
+		Code:    protocol.ErrorNotInLobby,
 		Message: "You are not in a lobby",
-	}
+	})
 
 	log.Fields{"client": client.Nickname}.Debug("client attempted to ready up, but was not in a lobby")
 }
 
 func engineHandleWord(e *Engine, client *Client, _ interface{}) {
-	client.OutgoingPipe <- clientErrorMessage{
+	client.Send(clientErrorMessage{
 		Command: "word",
+		Code:    protocol.ErrorNotInLobby,
 		Message: "You are not in a lobby",
-	}
+	})
 
 	log.Fields{"client": client.Nickname}.Debug("client attempted to guess a word, but was not in a lobby")
 }
+
+func engineHandleConfigure(e *Engine, client *Client, _ interface{}) {
+	client.Send(clientErrorMessage{
+		Command: "configure",
+		Code:    protocol.ErrorNotInLobby,
+		Message: "You are not in a lobby",
+	})
+
+	log.Fields{"client": client.Nickname}.Debug("client attempted to configure a lobby, but was not in a lobby")
+}
+
+func engineHandleList(e *Engine, client *Client, data interface{}) {
+	lobbies := e.publicLobbies()
+
+	if client == nil {
+		data.(chan []LobbySummary) <- lobbies
+		return
+	}
+
+	client.Send(clientLobbyListMessage{
+		Lobbies: lobbies,
+	})
+
+	log.Fields{"client": client.Nickname}.Debug("client listed lobbies")
+}
+
+func engineHandleDetach(e *Engine, client *Client, data interface{}) {
+	// Lobbies mark their own members as detached before forwarding; the flag
+	// belongs to whichever goroutine currently owns the client.
+	if markedByLobby, _ := data.(bool); !markedByLobby {
+		client.detached = true
+	}
+	stopReplay(client)
+
+	e.detached[client.resumeToken] = detachedClient{
+		client: client,
+		since:  time.Now(),
+	}
+
+	log.Fields{"client": client.Nickname}.Debug("client detached from engine; awaiting resumption")
+}
+
+func engineHandleResume(e *Engine, _ *Client, data interface{}) {
+	request := data.(resumeRequest)
+
+	detached, ok := e.detached[request.token]
+	if !ok {
+		request.reply <- nil
+		return
+	}
+
+	delete(e.detached, request.token)
+	client := detached.client
+	client.outbox.reset()
+	request.reply <- client
+
+	if client.Lobby != nil {
+		client.incomingPipe <- incomingMessage{
+			what:   messageTypeResume,
+			client: client,
+		}
+		return
+	}
+
+	client.detached = false
+	message := client.StateMessage("Welcome back; you are still known as " + client.Nickname)
+	message.ResumeToken = client.resumeToken
+	client.Send(message)
+
+	log.Fields{"client": client.Nickname}.Debug("client resumed session")
+}
+
+func engineHandleSpectate(e *Engine, client *Client, data interface{}) {
+	payload := data.(joinPayload)
+	normalizedName := strings.ToLower(payload.lobbyName)
+
+	lobby, ok := e.lobbies[normalizedName]
+	if !ok {
+		client.Send(clientErrorMessage{
+			Command: "spectate",
+			Code:    protocol.ErrorNotFound,
+			Message: "There is no lobby by that name to spectate",
+		})
+		return
+	}
+
+	if !admit(client, "spectate", lobby, payload.options.Passphrase) {
+		return
+	}
+
+	e.enterLobby(client, normalizedName, lobby, true)
+	log.Fields{"client": client.Nickname, "lobby": lobby.Name}.Debug("client spectating lobby")
+}
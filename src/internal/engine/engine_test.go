@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"internal/language"
+	"internal/log"
+	"internal/nickname"
+	"internal/protocol"
+)
+
+// configDirectory is the repository's config directory, relative to this
+// package.
+var configDirectory = path.Join("..", "..", "..", "config")
+
+func TestMain(m *testing.M) {
+	if err := language.Load(path.Join(configDirectory, "languages")); err != nil {
+		log.Fields{"error": err}.Fatal("couldn't load language packs")
+	}
+	if err := language.Load(path.Join("testdata", "languages")); err != nil {
+		log.Fields{"error": err}.Fatal("couldn't load test language packs")
+	}
+	if err := nickname.Load(configDirectory); err != nil {
+		log.Fields{"error": err}.Fatal("couldn't load nickname lists")
+	}
+	os.Exit(m.Run())
+}
+
+// newTestLobby returns an English 4x4 lobby that isn't running, for tests
+// that drive its handlers directly.
+func newTestLobby(e *Engine, name string) *lobby {
+	lang, _ := language.Get(language.Default)
+	cubes, _ := lang.Cubes(4)
+	d, _ := lang.Dictionary("")
+	return e.newLobby(name, lang, cubes, d, defaultLobbySettings(), nil, false)
+}
+
+// newTestClient returns a client that isn't connected to any engine.
+func newTestClient(id, nickname string) *Client {
+	return &Client{
+		ID:       id,
+		Nickname: nickname,
+		outbox:   newOutbox(),
+	}
+}
+
+// received drains the messages sent to a client, decoded as a client would.
+func received(t *testing.T, client *Client) []protocol.Message {
+	frames, _ := client.Drain()
+	messages := make([]protocol.Message, len(frames))
+	for i, frame := range frames {
+		message, err := protocol.DecodeMessage(frame)
+		if err != nil {
+			t.Fatalf("couldn't decode %s: %s", frame, err)
+		}
+		messages[i] = message
+	}
+	return messages
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"internal/log"
+	"internal/protocol"
+	"internal/store"
+)
+
+const historyBuffering = 64
+
+// maxTimelineEvents bounds how much of a game's timeline is kept, so that a
+// long game with many players cannot grow it without limit.
+const maxTimelineEvents = 8192
+
+// SetStore records every finished game in s. Writes happen on a separate
+// goroutine so a slow store never holds up a lobby; Shutdown waits for it to
+// write every game that has finished.
+func (e *Engine) SetStore(s store.Store) {
+	e.store = s
+	e.history = make(chan store.Game, historyBuffering)
+	e.historyStop = make(chan struct{})
+	e.historyDone = make(chan struct{})
+	go func() {
+		defer close(e.historyDone)
+
+		record := func(game store.Game) {
+			if err := s.RecordGame(game); err != nil {
+				log.Fields{"game": game.ID, "lobby": game.Lobby, "error": err}.Error("couldn't record finished game")
+			}
+		}
+
+		for {
+			select {
+			case game := <-e.history:
+				record(game)
+			case <-e.historyStop:
+				for {
+					select {
+					case game := <-e.history:
+						record(game)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// flushHistory waits for every game queued so far to be written to the store,
+// and stops writing. Games that finish afterwards are not recorded.
+func (e *Engine) flushHistory() {
+	if e.history == nil {
+		return
+	}
+
+	close(e.historyStop)
+	<-e.historyDone
+	log.Info("game history has been written")
+}
+
+func (l *lobby) recordGame(clients []*Client, wordlists [][]string, totals []int, scores [][]int) {
+	if l.history == nil && l.ratings == nil {
+		return
+	}
+
+	game := store.Game{
+		ID:         randomHex(8),
+		Lobby:      l.Name,
+		Language:   l.Language.Code,
+		Dictionary: l.Dictionary.Name,
+		Size:       l.Size,
+		Grid:       l.Grid,
+		Seed:       l.seed,
+		Settings: store.Settings{
+			GameDuration:         int(l.Settings.GameDuration / time.Second),
+			CountdownDuration:    int(l.Settings.CountdownDuration / time.Second),
+			IntermissionDuration: int(l.Settings.IntermissionDuration / time.Second),
+			MinimumPlayers:       l.Settings.MinimumPlayers,
+		},
+		StartedAt: l.startedAt,
+		EndedAt:   time.Now(),
+		Players:   make([]store.Player, len(clients)),
+		Events:    l.timeline,
+	}
+
+	for i, client := range clients {
+		player := store.Player{
+			ID:        client.ID,
+			AccountID: client.AccountID,
+			Nickname:  client.Nickname,
+			Score:     totals[i],
+			Words:     make([]store.Word, len(wordlists[i])),
+		}
+		for j, word := range wordlists[i] {
+			player.Words[j] = store.Word{
+				Word:   word,
+				Points: scores[i][j],
+			}
+		}
+		game.Players[i] = player
+	}
+
+	// Ratings are only recorded once the game is queued, so that those in
+	// memory match the history they are rebuilt from at startup.
+	l.rateGame(&game)
+	if l.history != nil {
+		select {
+		case l.history <- game:
+			l.GameID = game.ID
+		default:
+			log.Fields{"lobby": l.Name, "game": game.ID}.Error("game history is backed up; dropping finished game and its ratings")
+			return
+		}
+	}
+	l.recordRatings(&game, clients)
+}
+
+// startTimeline begins a new game's timeline with the players present when it
+// starts.
+func (l *lobby) startTimeline() {
+	l.timeline = nil
+	l.logEvent(nil, store.Event{Type: store.EventStart})
+
+	players := make(clientIdentities, 0, len(l.Clients))
+	for client := range l.Clients {
+		players = append(players, identify(client))
+	}
+	sort.Sort(players)
+	for _, player := range players {
+		l.timeline = append(l.timeline, store.Event{
+			Type:     store.EventJoin,
+			PlayerID: player.ID,
+			Nickname: player.Nickname,
+		})
+	}
+}
+
+// logEvent appends an event, attributed to client if it is not nil, to the
+// timeline of the game in progress.
+func (l *lobby) logEvent(client *Client, event store.Event) {
+	if l.State != protocol.StateInGame || len(l.timeline) >= maxTimelineEvents {
+		return
+	}
+
+	event.Offset = int64(time.Since(l.startedAt) / time.Millisecond)
+	if client != nil {
+		event.PlayerID = client.ID
+		event.Nickname = client.Nickname
+	}
+	l.timeline = append(l.timeline, event)
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"internal/rating"
+	"internal/store"
+)
+
+// slowStore takes a while to record each game.
+type slowStore struct {
+	*store.Memory
+}
+
+func (s slowStore) RecordGame(game store.Game) error {
+	time.Sleep(10 * time.Millisecond)
+	return s.Memory.RecordGame(game)
+}
+
+func TestShutdownFlushesHistory(t *testing.T) {
+	s := slowStore{store.NewMemory()}
+	e := New()
+	e.SetStore(s)
+	go e.Run()
+	defer e.Terminate()
+
+	for i := 0; i < historyBuffering; i++ {
+		e.history <- store.Game{ID: fmt.Sprintf("game-%d", i), EndedAt: time.Now()}
+	}
+
+	e.Shutdown(time.Now().Add(time.Second))
+
+	games, err := s.Games(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != historyBuffering {
+		t.Errorf("%d of %d games were written before Shutdown returned", len(games), historyBuffering)
+	}
+}
+
+func TestDroppedGameLeavesRatings(t *testing.T) {
+	e := New()
+	l := newTestLobby(e, "rated")
+	l.ratings = rating.New()
+
+	alice := newTestClient("a1", "Alice")
+	alice.AccountID = "account-a"
+	bob := newTestClient("b2", "Bob")
+	bob.AccountID = "account-b"
+	clients := []*Client{alice, bob}
+	wordlists := [][]string{{"tea"}, {}}
+	totals := []int{1, 0}
+	scores := [][]int{{1}, {}}
+
+	// Nobody is writing the history, so the game is dropped.
+	l.history = make(chan store.Game)
+	l.recordGame(clients, wordlists, totals, scores)
+	if a := l.ratings.Get(alice.AccountID); a.Games != 0 || alice.Rating != 0 {
+		t.Errorf("a dropped game changed ratings: table %+v, client %d", a, alice.Rating)
+	}
+
+	history := make(chan store.Game, 1)
+	l.history = history
+	l.recordGame(clients, wordlists, totals, scores)
+	game := <-history
+	a := l.ratings.Get(alice.AccountID)
+	if a.Games != 1 || a.Rating != game.Players[0].Rating || alice.Rating != a.Rating {
+		t.Errorf("a kept game left the table at %+v and the client at %d, want the game's %d", a, alice.Rating, game.Players[0].Rating)
+	}
+}
@@ -2,30 +2,21 @@ package engine
 
 import (
 	"fmt"
-	"regexp"
 	"sort"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"internal/dictionary"
 	"internal/grid"
+	"internal/language"
 	"internal/log"
+	"internal/protocol"
+	"internal/rating"
+	"internal/store"
 )
 
-const (
-	betweenGameDuration = 1 * time.Minute
-	countdownDuration   = 5 * time.Second
-	gameDuration        = 3 * time.Minute
-)
-
-const (
-	stateAwaitingPlayers = "awaitingPlayers"
-	stateBetweenGames    = "betweenGames"
-	stateCountdown       = "countdown"
-	stateInGame          = "inGame"
-)
-
-var wordRegex = regexp.MustCompile("^[a-zA-Z]+$")
-
 type lobby struct {
 	Name  string `json:"name"`
 	State string `json:"state"`
@@ -37,21 +28,57 @@ type lobby struct {
 	incomingPipe       chan incomingMessage
 	parentIncomingPipe chan incomingMessage
 
-	Clients clientSet `json:"players"`
-
-	Grid           grid.Grid   `json:"grid"`
-	MasterSolution *gameResult `json:"masterSolution,omitempty"`
+	Clients    clientSet    `json:"players"`
+	Spectators spectatorSet `json:"spectators"`
+	owner      *Client
+
+	Settings lobbySettings `json:"settings"`
+	Rated    bool          `json:"rated"`
+
+	passphrase  *passphrase
+	summary     atomic.Value
+	chatFilters []ChatFilter
+	history     chan<- store.Game
+	ratings     *rating.Table
+
+	draining *sync.WaitGroup
+	drained  bool
+
+	Language       *language.Language     `json:"language"`
+	Size           int                    `json:"size"`
+	Dictionary     *dictionary.Dictionary `json:"dictionary"`
+	Grid           grid.Grid              `json:"grid"`
+	MasterSolution *gameResult            `json:"masterSolution,omitempty"`
+	GameID         string                 `json:"gameId,omitempty"`
+	Challenge      *challengeRun          `json:"challenge,omitempty"`
+
+	cubes     grid.CubeSet
+	seed      int64
+	startedAt time.Time
+	timeline  []store.Event
 }
 
 type clientSet map[*Client]*clientData
 
+type spectatorSet map[*Client]struct{}
+
 type clientData struct {
 	Readied        bool `json:"readied"`
 	Score          int  `json:"score"`
+	WordsFound     int  `json:"wordsFound"`
 	words          []string
 	PreviousResult *gameResult `json:"result,omitempty"`
 }
 
+func (data *clientData) hasWord(word string) bool {
+	for _, w := range data.words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
 type gameResult struct {
 	Score int          `json:"score"`
 	Words []scoredWord `json:"words"`
@@ -60,6 +87,7 @@ type gameResult struct {
 type scoredWord struct {
 	Word   string `json:"word"`
 	Points int    `json:"points"`
+	Found  bool   `json:"found,omitempty"`
 }
 
 var lobbyDispatchTable = [messageTypeCount]func(*lobby, *Client, interface{}){
@@ -69,20 +97,43 @@ var lobbyDispatchTable = [messageTypeCount]func(*lobby, *Client, interface{}){
 	lobbyHandlePart,
 	lobbyHandleReady,
 	lobbyHandleWord,
+	lobbyHandleConfigure,
+	lobbyHandleList,
+	lobbyHandleDetach,
+	lobbyHandleResume,
+	lobbyHandleSpectate,
+	lobbyHandleChat,
+	lobbyHandleNick,
+	lobbyHandleReplay,
+	lobbyHandleChallenge,
+	lobbyHandleShutdown,
 }
 
-func (e *Engine) newLobby(name string) *lobby {
+func (e *Engine) newLobby(name string, lang *language.Language, cubes grid.CubeSet, d *dictionary.Dictionary, settings lobbySettings, p *passphrase, rated bool) *lobby {
 	l := lobby{
 		Name:               name,
-		State:              stateAwaitingPlayers,
+		State:              protocol.StateAwaitingPlayers,
 		asyncInterrupt:     time.NewTimer(0),
 		asyncTimestamp:     time.Now(),
 		terminator:         make(chan struct{}, 1),
 		incomingPipe:       newIncomingPipe(),
 		parentIncomingPipe: e.incomingPipe,
 		Clients:            map[*Client]*clientData{},
+		Spectators:         map[*Client]struct{}{},
+		Settings:           settings,
+		Rated:              rated,
+		passphrase:         p,
+		chatFilters:        e.chatFilters,
+		history:            e.history,
+		ratings:            e.ratings,
+		Language:           lang,
+		Size:               cubes.Size(),
+		Dictionary:         d,
+		Grid:               grid.New(cubes.Size()),
+		cubes:              cubes,
 	}
 	l.clearAsyncInterrupt()
+	l.publishSummary()
 	return &l
 }
 
@@ -105,6 +156,8 @@ func (l *lobby) run() {
 		}
 
 		l.transitionState()
+		l.checkDrained()
+		l.publishSummary()
 	}
 }
 
@@ -112,8 +165,17 @@ func (l *lobby) terminate() {
 	close(l.terminator)
 }
 
+// empty reports whether the lobby had nobody in it when it last published its
+// summary. Unlike its member sets, it may be called from any goroutine.
 func (l *lobby) empty() bool {
-	return len(l.Clients) == 0
+	summary := l.summary.Load().(LobbySummary)
+	return summary.Players == 0 && summary.Spectators == 0
+}
+
+func (l *lobby) contains(client *Client) bool {
+	_, player := l.Clients[client]
+	_, spectator := l.Spectators[client]
+	return player || spectator
 }
 
 func (l *lobby) readyPlayerCount() int {
@@ -141,47 +203,101 @@ func (l *lobby) resetAsyncInterrupt(d time.Duration) {
 	l.asyncTimestamp = time.Now().Add(d)
 }
 
+func (l *lobby) broadcast(message OutgoingMessage) {
+	data := marshal(message)
+	for client := range l.Clients {
+		if !client.detached {
+			client.outbox.push(data, false)
+		}
+	}
+	for client := range l.Spectators {
+		if !client.detached {
+			client.outbox.push(data, false)
+		}
+	}
+}
+
 func (l *lobby) broadcastState(memo string) {
 	for client := range l.Clients {
-		client.OutgoingPipe <- client.StateMessage(memo)
+		if !client.detached {
+			client.Send(client.StateMessage(memo))
+		}
 	}
+	l.broadcastSpectatorState(memo)
+}
+
+func (l *lobby) broadcastSpectatorState(memo string) {
+	for client := range l.Spectators {
+		if !client.detached {
+			client.Send(client.StateMessage(memo))
+		}
+	}
+}
+
+// rejectSpectator reports whether the client is only watching the lobby, and
+// if so tells them they may not issue the given command.
+func (l *lobby) rejectSpectator(client *Client, command string) bool {
+	if _, ok := l.Spectators[client]; !ok {
+		return false
+	}
+
+	client.Send(clientErrorMessage{
+		Command: command,
+		Code:    protocol.ErrorForbidden,
+		Message: "Spectators may not do that; part the lobby and join it to play",
+	})
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname, "command": command}.Debug("spectator tried to play")
+	return true
 }
 
 func (l *lobby) transitionState() {
+	if l.draining != nil && l.State != protocol.StateInGame {
+		return
+	}
+
 	asyncEvent := time.Now().After(l.asyncTimestamp)
 
 	transition := true
 	memo := ""
 
 	switch l.State {
-	case stateAwaitingPlayers:
-		if len(l.Clients) >= 2 {
+	case protocol.StateAwaitingPlayers:
+		if l.Challenge != nil {
+			if l.Challenge.started || len(l.Clients) == 0 {
+				transition = false
+				break
+			}
+			log.Fields{"lobby": l.Name}.Debug("daily challenge player has arrived")
+			l.transitionToCountdown()
+			memo = fmt.Sprintf("The daily challenge starts in %d seconds", l.Settings.CountdownDuration/time.Second)
+		} else if len(l.Clients) >= l.Settings.MinimumPlayers {
 			log.Fields{"lobby": l.Name}.Debug("lobby was awaitingPlayers, but now sufficient players are here")
 			l.transitionToBetweenGames()
-			memo = fmt.Sprintf("Sufficient players; countdown to next game starts in %d seconds", betweenGameDuration/time.Second)
+			memo = fmt.Sprintf("Sufficient players; countdown to next game starts in %d seconds", l.Settings.IntermissionDuration/time.Second)
 		} else {
 			transition = false
 		}
 
-	case stateBetweenGames:
-		if len(l.Clients) <= 1 {
+	case protocol.StateBetweenGames:
+		if len(l.Clients) < l.Settings.MinimumPlayers {
 			log.Fields{"lobby": l.Name}.Debug("lobby was betweenGames, but now insufficient players are here")
 			l.transitionToAwaitingPlayers()
 			memo = "Insufficient players to start the game; waiting for more..."
 		} else if asyncEvent {
 			log.Fields{"lobby": l.Name}.Debug("lobby was betweenGames, but the timer has elapsed")
 			l.transitionToCountdown()
-			memo = fmt.Sprintf("Waiting period is over; game starts in %d seconds", countdownDuration/time.Second)
+			memo = fmt.Sprintf("Waiting period is over; game starts in %d seconds", l.Settings.CountdownDuration/time.Second)
 		} else if l.readyPlayerCount() == len(l.Clients) {
 			log.Fields{"lobby": l.Name}.Debug("lobby was betweenGames, but all players have readied up")
 			l.transitionToCountdown()
-			memo = fmt.Sprintf("Everyone is ready for the next game; game starts in %d seconds", countdownDuration/time.Second)
+			memo = fmt.Sprintf("Everyone is ready for the next game; game starts in %d seconds", l.Settings.CountdownDuration/time.Second)
 		} else {
 			transition = false
 		}
 
-	case stateCountdown:
-		if len(l.Clients) <= 1 {
+	case protocol.StateCountdown:
+		if len(l.Clients) < l.Settings.MinimumPlayers {
 			log.Fields{"lobby": l.Name}.Debug("lobby was in countdown, but now insufficient players are here")
 			l.transitionToAwaitingPlayers()
 			memo = "Insufficient players to start the game; waiting for more..."
@@ -193,11 +309,11 @@ func (l *lobby) transitionState() {
 			transition = false
 		}
 
-	case stateInGame:
+	case protocol.StateInGame:
 		if asyncEvent {
 			log.Fields{"lobby": l.Name}.Debug("lobby was inGame, but the timer has elapsed")
 			l.endGame()
-			if len(l.Clients) <= 1 {
+			if l.Challenge != nil || len(l.Clients) < l.Settings.MinimumPlayers {
 				l.transitionToAwaitingPlayers()
 			} else {
 				l.transitionToBetweenGames()
@@ -219,15 +335,23 @@ func (l *lobby) transitionState() {
 func (l *lobby) endGame() {
 	log.Fields{"lobby": l.Name}.Debug("game is over; scoring")
 
+	orderedClients := make([]*Client, 0, len(l.Clients))
 	orderedClientData := make([]*clientData, 0, len(l.Clients))
 	wordlists := make([][]string, 0, len(l.Clients))
-	for _, data := range l.Clients {
+	for client, data := range l.Clients {
 		sort.Strings(data.words)
 		wordlists = append(wordlists, data.words)
+		orderedClients = append(orderedClients, client)
 		orderedClientData = append(orderedClientData, data)
 	}
 
-	totals, scores, solution, masterTotal, masterScores := l.Grid.Score(wordlists)
+	totals, scores, solution, masterTotal, masterScores := l.Grid.Score(l.Dictionary, wordlists)
+	l.logEvent(nil, store.Event{Type: store.EventEnd})
+	if l.Challenge != nil {
+		l.finishChallenge(orderedClients, wordlists, totals, scores)
+	} else {
+		l.recordGame(orderedClients, wordlists, totals, scores)
+	}
 	for i, clientData := range orderedClientData {
 		clientData.Score += totals[i]
 		clientData.PreviousResult = &gameResult{
@@ -243,23 +367,45 @@ func (l *lobby) endGame() {
 		}
 	}
 
+	found := map[string]bool{}
+	for _, list := range wordlists {
+		for _, word := range list {
+			found[word] = true
+		}
+	}
+
+	// A lone player has nobody to share words with, so score the master
+	// solution against everything on the board rather than what was missed.
+	solo := len(wordlists) == 1
+	if solo {
+		masterTotal = 0
+	}
+
 	l.MasterSolution = &gameResult{
-		Score: masterTotal,
 		Words: make([]scoredWord, len(solution)),
 	}
 
 	for i, word := range solution {
+		points := masterScores[i]
+		if solo {
+			points = grid.Points(word)
+			masterTotal += points
+		}
+
+		word = l.Language.Lower(word)
 		l.MasterSolution.Words[i] = scoredWord{
-			Word:   strings.ToLower(word),
-			Points: masterScores[i],
+			Word:   word,
+			Points: points,
+			Found:  found[word],
 		}
 	}
+	l.MasterSolution.Score = masterTotal
 }
 
 func (l *lobby) transitionToAwaitingPlayers() {
 	log.Fields{"lobby": l.Name}.Debug("state transition to awaitingPlayers")
 	l.clearAsyncInterrupt()
-	l.State = stateAwaitingPlayers
+	l.State = protocol.StateAwaitingPlayers
 	for _, data := range l.Clients {
 		data.Readied = false
 	}
@@ -267,8 +413,8 @@ func (l *lobby) transitionToAwaitingPlayers() {
 
 func (l *lobby) transitionToBetweenGames() {
 	log.Fields{"lobby": l.Name}.Debug("state transition to betweenGames")
-	l.resetAsyncInterrupt(betweenGameDuration)
-	l.State = stateBetweenGames
+	l.resetAsyncInterrupt(l.Settings.IntermissionDuration)
+	l.State = protocol.StateBetweenGames
 	for _, data := range l.Clients {
 		data.Readied = false
 	}
@@ -276,37 +422,57 @@ func (l *lobby) transitionToBetweenGames() {
 
 func (l *lobby) transitionToCountdown() {
 	log.Fields{"lobby": l.Name}.Debug("state transition to countdown")
-	l.resetAsyncInterrupt(countdownDuration)
-	l.State = stateCountdown
+	l.resetAsyncInterrupt(l.Settings.CountdownDuration)
+	l.State = protocol.StateCountdown
 	for _, data := range l.Clients {
 		data.Readied = false
 		data.words = data.words[:0]
+		data.WordsFound = 0
 		data.PreviousResult = nil
 	}
 	l.MasterSolution = nil
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			l.Grid[i][j] = ""
-		}
-	}
+	l.GameID = ""
+	l.Grid = grid.New(l.Size)
 }
 
 func (l *lobby) transitionToInGame() {
-	l.resetAsyncInterrupt(gameDuration)
-	l.State = stateInGame
-	l.Grid = grid.Generate(nil)
+	l.resetAsyncInterrupt(l.Settings.GameDuration)
+	l.State = protocol.StateInGame
+	if l.Challenge != nil {
+		l.Challenge.started = true
+		l.seed = l.Challenge.seed
+		l.Grid = grid.GenerateFromSeed(l.cubes, l.seed)
+	} else {
+		l.Grid = grid.Generate(l.cubes, &l.seed)
+	}
+	l.startedAt = time.Now()
+	l.startTimeline()
 	log.Fields{"lobby": l.Name}.Debug("state transition to inGame")
 }
 
-func lobbyHandleNew(l *lobby, client *Client, _ interface{}) {
+func lobbyHandleNew(l *lobby, client *Client, data interface{}) {
+	if spectator, _ := data.(bool); spectator {
+		l.Spectators[client] = struct{}{}
+		client.Spectator = true
+		l.broadcastState(client.Nickname + " is now spectating " + l.Name)
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client is spectating lobby")
+		return
+	}
+
 	l.Clients[client] = &clientData{
 		Readied: false,
 		Score:   0,
 	}
+	if l.owner == nil {
+		l.owner = client
+	}
+
+	l.logEvent(client, store.Event{Type: store.EventJoin})
 	l.broadcastState(client.Nickname + " has joined " + l.Name)
 
-	if l.State != stateAwaitingPlayers && l.State != stateBetweenGames {
-		client.OutgoingPipe <- client.StateMessage("A game is already in progress")
+	if l.State != protocol.StateAwaitingPlayers && l.State != protocol.StateBetweenGames {
+		client.Send(client.StateMessage("A game is already in progress"))
 	}
 
 	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client joined lobby")
@@ -318,31 +484,63 @@ func lobbyHandleQuit(l *lobby, client *Client, _ interface{}) {
 }
 
 func lobbyHandleJoin(l *lobby, client *Client, _ interface{}) {
-	client.OutgoingPipe <- clientErrorMessage{
+	client.Send(clientErrorMessage{
 		Command: "join",
+		Code:    protocol.ErrorAlreadyInLobby,
 		Message: "You are already in a lobby",
-	}
+	})
 
 	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to join, but is already in a lobby")
 }
 
+func lobbyHandleSpectate(l *lobby, client *Client, _ interface{}) {
+	client.Send(clientErrorMessage{
+		Command: "spectate",
+		Code:    protocol.ErrorAlreadyInLobby,
+		Message: "You are already in a lobby",
+	})
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to spectate, but is already in a lobby")
+}
+
 func lobbyHandlePart(l *lobby, client *Client, _ interface{}) {
+	if _, ok := l.Clients[client]; ok {
+		l.logEvent(client, store.Event{Type: store.EventPart})
+	}
+
 	delete(l.Clients, client)
+	delete(l.Spectators, client)
+	client.Spectator = false
+	if l.owner == client {
+		l.owner = nil
+		for successor := range l.Clients {
+			l.owner = successor
+			break
+		}
+	}
+
 	client.Lobby = nil
 	client.incomingPipe = l.parentIncomingPipe
 
-	client.OutgoingPipe <- client.StateMessage("You have left " + l.Name)
+	if !client.detached {
+		client.Send(client.StateMessage("You have left " + l.Name))
+	}
 	l.broadcastState(client.Nickname + " has left " + l.Name)
 
 	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client parted lobby")
 }
 
 func lobbyHandleReady(l *lobby, client *Client, _ interface{}) {
-	if l.State != stateBetweenGames {
-		client.OutgoingPipe <- clientErrorMessage{
+	if l.rejectSpectator(client, "ready") {
+		return
+	}
+
+	if l.State != protocol.StateBetweenGames {
+		client.Send(clientErrorMessage{
 			Command: "ready",
+			Code:    protocol.ErrorWrongState,
 			Message: "You may only ready up between games",
-		}
+		})
 
 		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to ready up, but lobby is not between games")
 		return
@@ -357,31 +555,151 @@ func lobbyHandleReady(l *lobby, client *Client, _ interface{}) {
 func lobbyHandleWord(l *lobby, client *Client, data interface{}) {
 	word := data.(string)
 
-	if l.State != stateInGame {
-		client.OutgoingPipe <- clientErrorMessage{
+	if l.rejectSpectator(client, "word") {
+		return
+	}
+
+	if l.State != protocol.StateInGame {
+		client.Send(clientErrorMessage{
 			Command: "word",
+			Code:    protocol.ErrorWrongState,
 			Message: "You may only record a word during a game",
-		}
+		})
 
 		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to record a word, but lobby is not in-game")
 		return
 	}
 
-	if !wordRegex.MatchString(word) {
-		client.OutgoingPipe <- clientErrorMessage{
+	if !l.Language.Valid(word) {
+		client.Send(clientErrorMessage{
 			Command: "word",
+			Code:    protocol.ErrorBadArgument,
 			Message: "You may record only single, non-empty words, containing only letters",
-		}
+		})
 
 		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to record a word, but word was malformed")
 		return
 	}
 
-	word = strings.ToLower(word)
+	// Words are kept folded, so that spellings the dictionary treats as the
+	// same word are also the same word to duplicate checks and scoring.
+	word = l.Language.Lower(l.Dictionary.Fold(word))
+	player := l.Clients[client]
 
-	l.Clients[client].words = append(l.Clients[client].words, word)
-	client.OutgoingPipe <- clientWordMessage{
+	response := clientWordMessage{
 		Word: word,
 	}
-	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client recorded a word")
+
+	if utf8.RuneCountInString(word) < grid.MinimumWordLength {
+		response.Status = protocol.WordTooShort
+	} else if player.hasWord(word) {
+		response.Status = protocol.WordDuplicate
+	} else if response.Path = l.Grid.Trace(l.Dictionary, word); response.Path == nil {
+		response.Status = protocol.WordNotOnBoard
+	} else if !l.Dictionary.Contains(l.Dictionary.Fold(word)) {
+		response.Status = protocol.WordNotInDictionary
+		response.Path = nil
+	} else {
+		response.Status = protocol.WordValid
+		player.words = append(player.words, word)
+		player.WordsFound = len(player.words)
+		l.broadcastSpectatorState("")
+	}
+
+	l.logEvent(client, store.Event{
+		Type:   store.EventWord,
+		Word:   word,
+		Status: response.Status,
+	})
+	client.Send(response)
+	log.Fields{"lobby": l.Name, "client": client.Nickname, "status": response.Status}.Debug("client submitted a word")
+}
+
+func lobbyHandleConfigure(l *lobby, client *Client, data interface{}) {
+	request := data.(SettingsRequest)
+
+	if l.Challenge != nil {
+		client.Send(clientErrorMessage{
+			Command: "configure",
+			Code:    protocol.ErrorForbidden,
+			Message: "The daily challenge's settings are fixed",
+		})
+		return
+	}
+
+	if client != l.owner {
+		client.Send(clientErrorMessage{
+			Command: "configure",
+			Code:    protocol.ErrorForbidden,
+			Message: "Only the lobby owner may change its settings",
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to configure lobby, but is not the owner")
+		return
+	}
+
+	if l.State != protocol.StateAwaitingPlayers && l.State != protocol.StateBetweenGames {
+		client.Send(clientErrorMessage{
+			Command: "configure",
+			Code:    protocol.ErrorWrongState,
+			Message: "You may only change settings between games",
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to configure lobby, but a game is underway")
+		return
+	}
+
+	settings, err := l.Settings.apply(request)
+	if err != nil {
+		client.Send(clientErrorMessage{
+			Command: "configure",
+			Code:    protocol.ErrorBadArgument,
+			Message: err.Error(),
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname, "error": err}.Debug("client tried to configure lobby, but settings were invalid")
+		return
+	}
+
+	l.Settings = settings
+	l.broadcastState(client.Nickname + " has changed the lobby settings")
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client configured lobby")
+}
+
+func lobbyHandleList(l *lobby, client *Client, _ interface{}) {
+	l.parentIncomingPipe <- incomingMessage{
+		what:   messageTypeList,
+		client: client,
+	}
+}
+
+func lobbyHandleDetach(l *lobby, client *Client, _ interface{}) {
+	client.detached = true
+	if _, ok := l.Clients[client]; ok {
+		l.logEvent(client, store.Event{Type: store.EventDetach})
+	}
+	l.broadcastState(client.Nickname + " has lost their connection")
+
+	l.parentIncomingPipe <- incomingMessage{
+		what:    messageTypeDetach,
+		client:  client,
+		payload: true,
+	}
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client detached from lobby")
+}
+
+func lobbyHandleResume(l *lobby, client *Client, _ interface{}) {
+	client.detached = false
+	if _, ok := l.Clients[client]; ok {
+		l.logEvent(client, store.Event{Type: store.EventResume})
+	}
+
+	message := client.StateMessage("Welcome back; you are still known as " + client.Nickname)
+	message.ResumeToken = client.resumeToken
+	client.Send(message)
+	l.broadcastState(client.Nickname + " has reconnected")
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client resumed session in lobby")
 }
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"internal/grid"
+	"internal/language"
+	"internal/protocol"
+)
+
+func TestAccentedWordIsFolded(t *testing.T) {
+	// The test pack folds accented vowels away, as Spanish does.
+	lang, _ := language.Get("xx")
+	cubes, _ := lang.Cubes(4)
+	d, _ := lang.Dictionary("")
+	l := New().newLobby("accents", lang, cubes, d, defaultLobbySettings(), nil, false)
+	l.Grid = grid.Grid{
+		{"T", "O", "R", "A"},
+		{"E", "E", "E", "E"},
+		{"E", "E", "E", "E"},
+		{"E", "E", "E", "E"},
+	}
+	l.State = protocol.StateInGame
+	l.startedAt = time.Now()
+
+	alice := newTestClient("a1", "Alice")
+	alice.Lobby = l
+	l.Clients[alice] = &clientData{}
+
+	submissions := []struct {
+		word   string
+		status string
+	}{
+		{"tóra", protocol.WordValid},
+		{"tora", protocol.WordDuplicate},
+		{"TÓRA", protocol.WordDuplicate},
+	}
+	for _, s := range submissions {
+		lobbyHandleWord(l, alice, s.word)
+
+		messages := received(t, alice)
+		if len(messages) != 1 {
+			t.Fatalf("submitting %q sent %d messages, want 1", s.word, len(messages))
+		}
+		word, ok := messages[0].(*protocol.Word)
+		if !ok {
+			t.Fatalf("submitting %q sent a %T, want a word", s.word, messages[0])
+		}
+		if word.Status != s.status {
+			t.Errorf("%q is %s, want %s", s.word, word.Status, s.status)
+		}
+	}
+
+	l.endGame()
+
+	result := l.Clients[alice].PreviousResult
+	if result.Score != grid.Points("TORA") || len(result.Words) != 1 || result.Words[0].Word != "tora" {
+		t.Errorf("scored %+v, want one word worth %d", result, grid.Points("TORA"))
+	}
+
+	for _, word := range l.MasterSolution.Words {
+		if word.Word == "tora" && !word.Found {
+			t.Error("master solution doesn't mark the accented submission as found")
+		}
+	}
+}
+
+func TestGarbageCollectLobbies(t *testing.T) {
+	e := New()
+	abandoned := newTestLobby(e, "abandoned")
+	occupied := newTestLobby(e, "occupied")
+	watched := newTestLobby(e, "watched")
+	recent := newTestLobby(e, "recent")
+
+	occupied.Clients[newTestClient("p1", "Player")] = &clientData{}
+	watched.Spectators[newTestClient("s1", "Spectator")] = struct{}{}
+	for _, l := range []*lobby{abandoned, occupied, watched, recent} {
+		l.publishSummary()
+		e.lobbies[l.Name] = l
+		e.joinedAt[l.Name] = time.Now().Add(-2 * lobbyTimeToLive)
+	}
+	e.joinedAt[recent.Name] = time.Now()
+
+	e.garbageCollectLobbies()
+
+	for _, name := range []string{"occupied", "watched", "recent"} {
+		if _, ok := e.lobbies[name]; !ok {
+			t.Errorf("lobby %q was collected", name)
+		}
+	}
+	if _, ok := e.lobbies["abandoned"]; ok {
+		t.Error("the abandoned lobby was not collected")
+	}
+}
+
+func TestJoinExistingLobbyWithOtherOptions(t *testing.T) {
+	e := New()
+	l := newTestLobby(e, "existing")
+	e.lobbies["existing"] = l
+
+	cases := []struct {
+		options JoinOptions
+		admit   bool
+	}{
+		{JoinOptions{}, true},
+		{JoinOptions{Language: "en", Size: 4, Dictionary: l.Dictionary.Name}, true},
+		{JoinOptions{Settings: SettingsRequest{GameDuration: defaultGameDuration}}, true},
+		{JoinOptions{Settings: SettingsRequest{GameDuration: time.Minute}}, false},
+		{JoinOptions{Settings: SettingsRequest{MinimumPlayers: 1}}, false},
+		{JoinOptions{Size: 5}, false},
+		{JoinOptions{Language: "xx"}, false},
+		{JoinOptions{Rated: true}, false},
+	}
+
+	for _, c := range cases {
+		client := newTestClient("c1", "Carol")
+		client.incomingPipe = e.incomingPipe
+		engineHandleJoin(e, client, joinPayload{lobbyName: "Existing", options: c.options})
+
+		if admitted := client.Lobby == l; admitted != c.admit {
+			t.Errorf("joining with %+v admitted %t, want %t", c.options, admitted, c.admit)
+		}
+		if c.admit {
+			<-l.incomingPipe
+			continue
+		}
+
+		messages := received(t, client)
+		if len(messages) != 1 {
+			t.Fatalf("joining with %+v sent %d messages, want 1", c.options, len(messages))
+		}
+		if refusal, ok := messages[0].(*protocol.Error); !ok || refusal.Code != protocol.ErrorLobbyExists {
+			t.Errorf("joining with %+v sent %+v, want a lobby_exists error", c.options, messages[0])
+		}
+	}
+}
@@ -2,14 +2,14 @@ package engine
 
 import (
 	"encoding/json"
-	"time"
-)
+	"sort"
 
-const (
-	incomingBuffering = 16384
-	outgoingBuffering = 128
+	"internal/grid"
+	"internal/protocol"
 )
 
+const incomingBuffering = 16384
+
 type incomingMessage struct {
 	what    incomingMessageType
 	client  *Client
@@ -22,10 +22,6 @@ func newIncomingPipe() chan incomingMessage {
 	return make(chan incomingMessage, incomingBuffering)
 }
 
-func newOutgoingPipe() chan OutgoingMessage {
-	return make(chan OutgoingMessage, outgoingBuffering)
-}
-
 type incomingMessageType int
 
 const (
@@ -35,21 +31,72 @@ const (
 	messageTypePart
 	messageTypeReady
 	messageTypeWord
+	messageTypeConfigure
+	messageTypeList
+	messageTypeDetach
+	messageTypeResume
+	messageTypeSpectate
+	messageTypeChat
+	messageTypeNick
+	messageTypeReplay
+	messageTypeChallenge
+	messageTypeShutdown
 	messageTypeCount
 )
 
+type joinPayload struct {
+	lobbyName string
+	options   JoinOptions
+}
+
+type resumeRequest struct {
+	token string
+	reply chan *Client
+}
+
 type clientStateMessage struct {
-	Message string `json:"message,omitempty"`
+	Message     string `json:"message,omitempty"`
+	ResumeToken string `json:"resumeToken,omitempty"`
 	*Client
 }
 
 type clientErrorMessage struct {
 	Command string `json:"command"`
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
 type clientWordMessage struct {
-	Word string `json:"word"`
+	Word   string      `json:"word"`
+	Status string      `json:"status"`
+	Path   []grid.Cell `json:"path,omitempty"`
+}
+
+type clientChatMessage struct {
+	FromID    string `json:"fromId"`
+	From      string `json:"from"`
+	Text      string `json:"text"`
+	Spectator bool   `json:"spectator,omitempty"`
+}
+
+type clientLobbyListMessage struct {
+	Lobbies []LobbySummary `json:"lobbies"`
+}
+
+func ErrorMessage(command, code, message string) OutgoingMessage {
+	return clientErrorMessage{
+		Command: command,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func ResumeFailedMessage() OutgoingMessage {
+	return clientErrorMessage{
+		Command: "resume",
+		Code:    protocol.ErrorSessionExpired,
+		Message: "Your previous session has expired; starting a new one",
+	}
 }
 
 func (c *Client) StateMessage(memo string) clientStateMessage {
@@ -59,27 +106,79 @@ func (c *Client) StateMessage(memo string) clientStateMessage {
 	}
 }
 
+type clientIdentity struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId,omitempty"`
+	Nickname  string `json:"nickname"`
+	Rating    int    `json:"rating,omitempty"`
+}
+
+type clientIdentities []clientIdentity
+
+func (c clientIdentities) Len() int      { return len(c) }
+func (c clientIdentities) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c clientIdentities) Less(i, j int) bool {
+	if c[i].Nickname != c[j].Nickname {
+		return c[i].Nickname < c[j].Nickname
+	}
+	return c[i].ID < c[j].ID
+}
+
+func identify(client *Client) clientIdentity {
+	return clientIdentity{
+		ID:        client.ID,
+		AccountID: client.AccountID,
+		Nickname:  client.Nickname,
+		Rating:    client.Rating,
+	}
+}
+
+type playerState struct {
+	clientIdentity
+	*clientData
+}
+
 func (c clientSet) MarshalJSON() ([]byte, error) {
-	result := map[string]*clientData{}
+	identities := make(clientIdentities, 0, len(c))
+	byID := make(map[string]*clientData, len(c))
 	for client, data := range c {
-		result[client.Nickname] = data
+		identities = append(identities, identify(client))
+		byID[client.ID] = data
+	}
+	sort.Sort(identities)
+
+	result := make([]playerState, len(identities))
+	for i, identity := range identities {
+		result[i] = playerState{identity, byID[identity.ID]}
 	}
 	return json.Marshal(result)
 }
 
+func (s spectatorSet) MarshalJSON() ([]byte, error) {
+	result := make(clientIdentities, 0, len(s))
+	for client := range s {
+		result = append(result, identify(client))
+	}
+	sort.Sort(result)
+	return json.Marshal(result)
+}
+
 func (l *lobby) MarshalJSON() ([]byte, error) {
-	remaining := (float64)(l.asyncTimestamp.Sub(time.Now())) / (float64)(time.Second)
-	ptr := &remaining
-	if remaining < 0 {
-		ptr = nil
+	owner := ""
+	if l.owner != nil {
+		owner = l.owner.ID
 	}
 
 	type Alias lobby
 	return json.Marshal(&struct {
 		SecondsRemaining *float64 `json:"secondsRemaining,omitempty"`
+		Owner            string   `json:"owner,omitempty"`
+		Private          bool     `json:"private"`
 		*Alias
 	}{
-		SecondsRemaining: ptr,
+		SecondsRemaining: secondsUntil(l.asyncTimestamp),
+		Owner:            owner,
+		Private:          l.passphrase != nil,
 		Alias:            (*Alias)(l),
 	})
 }
@@ -116,3 +215,25 @@ func (m clientWordMessage) MarshalJSON() ([]byte, error) {
 		Alias: (Alias)(m),
 	})
 }
+
+func (m clientLobbyListMessage) MarshalJSON() ([]byte, error) {
+	type Alias clientLobbyListMessage
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Alias
+	}{
+		Type:  "lobbies",
+		Alias: (Alias)(m),
+	})
+}
+
+func (m clientChatMessage) MarshalJSON() ([]byte, error) {
+	type Alias clientChatMessage
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Alias
+	}{
+		Type:  "chat",
+		Alias: (Alias)(m),
+	})
+}
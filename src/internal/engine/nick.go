@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"internal/account"
+	"internal/log"
+	"internal/nickname"
+	"internal/protocol"
+)
+
+type nickRequest struct {
+	nickname string
+
+	// forwarded is set once a lobby has checked that a rename is allowed in
+	// its current state; approved is set once the engine has reserved the
+	// new name; applied is set once a lobby has renamed the client, so the
+	// engine may free the old name.
+	forwarded bool
+	approved  bool
+	applied   bool
+	previous  string
+}
+
+func (c *Client) Nick(nick string) {
+	c.incomingPipe <- incomingMessage{
+		what:    messageTypeNick,
+		client:  c,
+		payload: nickRequest{nickname: nick},
+	}
+}
+
+func engineHandleNick(e *Engine, client *Client, data interface{}) {
+	request := data.(nickRequest)
+
+	if request.applied {
+		e.freeNickname(request.previous, request.nickname)
+		return
+	}
+
+	if request.approved {
+		if client.quit {
+			// The client quit while its lobby was renaming it; the name
+			// reserved for it would otherwise never be freed.
+			e.freeNickname(request.nickname, request.previous)
+			return
+		}
+		e.freeNickname(request.previous, request.nickname)
+		renameClient(client, request.previous, request.nickname)
+		return
+	}
+
+	nick := nickname.Normalize(request.nickname)
+	if err := nickname.Validate(nick); err != nil {
+		client.Send(clientErrorMessage{
+			Command: "nick",
+			Code:    protocol.ErrorBadArgument,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if e.registeredToOther(client, nick) {
+		client.Send(clientErrorMessage{
+			Command: "nick",
+			Code:    protocol.ErrorNameTaken,
+			Message: "That nickname belongs to a registered player",
+		})
+		return
+	}
+
+	// A client changing only the case of its name already holds it.
+	if !nickname.Same(nick, client.Nickname) && !e.nicknameGenerator.Reserve(nick) {
+		client.Send(clientErrorMessage{
+			Command: "nick",
+			Code:    protocol.ErrorNameTaken,
+			Message: "That nickname is already taken",
+		})
+		return
+	}
+
+	request.previous = client.Nickname
+	request.nickname = nick
+	request.approved = true
+
+	if request.forwarded {
+		client.incomingPipe <- incomingMessage{
+			what:    messageTypeNick,
+			client:  client,
+			payload: request,
+		}
+		return
+	}
+
+	e.freeNickname(request.previous, nick)
+	renameClient(client, request.previous, nick)
+}
+
+// freeNickname frees a name the client no longer needs, unless it is still
+// holding it as kept, the name it goes by now.
+func (e *Engine) freeNickname(name, kept string) {
+	if !nickname.Same(name, kept) {
+		e.nicknameGenerator.Free(name)
+	}
+}
+
+// SetAccounts lets the engine keep registered usernames for their owners.
+func (e *Engine) SetAccounts(accounts account.Store) {
+	e.accounts = accounts
+}
+
+func (e *Engine) registeredToOther(client *Client, nick string) bool {
+	if e.accounts == nil {
+		return false
+	}
+
+	a, err := e.accounts.ByUsername(nick)
+	return err == nil && a.ID != client.AccountID
+}
+
+func renameClient(client *Client, previous, nick string) {
+	client.Nickname = nick
+	client.Send(client.StateMessage("You are now known as " + nick))
+	log.Fields{"client": nick, "previous": previous}.Debug("client changed nickname")
+}
+
+func lobbyHandleNick(l *lobby, client *Client, data interface{}) {
+	request := data.(nickRequest)
+
+	if request.approved {
+		if !l.contains(client) {
+			// The client left while the engine was reserving the name; the
+			// engine owns it again, so let the engine finish the rename.
+			l.parentIncomingPipe <- incomingMessage{
+				what:    messageTypeNick,
+				client:  client,
+				payload: request,
+			}
+			return
+		}
+
+		client.Nickname = request.nickname
+		l.broadcastState(request.previous + " is now known as " + request.nickname)
+		log.Fields{"lobby": l.Name, "client": client.Nickname, "previous": request.previous}.Debug("client changed nickname")
+
+		request.applied = true
+		l.parentIncomingPipe <- incomingMessage{
+			what:    messageTypeNick,
+			client:  client,
+			payload: request,
+		}
+		return
+	}
+
+	if l.State == protocol.StateCountdown || l.State == protocol.StateInGame {
+		client.Send(clientErrorMessage{
+			Command: "nick",
+			Code:    protocol.ErrorWrongState,
+			Message: "You may not change your nickname during a game",
+		})
+
+		log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to change nickname mid-game")
+		return
+	}
+
+	request.forwarded = true
+	l.parentIncomingPipe <- incomingMessage{
+		what:    messageTypeNick,
+		client:  client,
+		payload: request,
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"testing"
+)
+
+// newNamedClient returns a client outside any lobby holding its nickname's
+// reservation in e.
+func newNamedClient(e *Engine, id, nick string) *Client {
+	client := newTestClient(id, nick)
+	client.incomingPipe = e.incomingPipe
+	e.nicknameGenerator.Reserve(nick)
+	return client
+}
+
+func TestNickChangesCase(t *testing.T) {
+	e := New()
+	bob := newNamedClient(e, "b1", "bob")
+
+	engineHandleNick(e, bob, nickRequest{nickname: "Bob"})
+	if bob.Nickname != "Bob" {
+		t.Fatalf("nickname is %q after renaming to Bob: %v", bob.Nickname, received(t, bob))
+	}
+	if e.nicknameGenerator.Reserve("BOB") {
+		t.Error("renaming by case freed the name")
+	}
+}
+
+func TestNickFreesPreviousName(t *testing.T) {
+	e := New()
+	alice := newNamedClient(e, "a1", "Alice")
+
+	engineHandleNick(e, alice, nickRequest{nickname: "Carol"})
+	if alice.Nickname != "Carol" {
+		t.Fatalf("nickname is %q after renaming to Carol", alice.Nickname)
+	}
+	if !e.nicknameGenerator.Reserve("Alice") {
+		t.Error("the previous name is still reserved")
+	}
+	if e.nicknameGenerator.Reserve("Carol") {
+		t.Error("the new name is not reserved")
+	}
+}
+
+func TestNickAppliedByLobby(t *testing.T) {
+	e := New()
+	alice := newNamedClient(e, "a1", "Alice")
+
+	// The lobby the client is in forwards the request, and is handed back
+	// the approved rename.
+	lobbyPipe := make(chan incomingMessage, 1)
+	alice.incomingPipe = lobbyPipe
+	engineHandleNick(e, alice, nickRequest{nickname: "Carol", forwarded: true})
+	approved := (<-lobbyPipe).payload.(nickRequest)
+
+	if e.nicknameGenerator.Reserve("Alice") {
+		t.Fatal("the previous name was freed before the lobby renamed the client")
+	}
+
+	alice.Nickname = approved.nickname
+	approved.applied = true
+	engineHandleNick(e, alice, approved)
+	if !e.nicknameGenerator.Reserve("Alice") {
+		t.Error("the previous name is still reserved once the lobby renamed the client")
+	}
+}
+
+func TestNickFreedWhenClientQuitsMidRename(t *testing.T) {
+	e := New()
+	alice := newNamedClient(e, "a1", "Alice")
+
+	lobbyPipe := make(chan incomingMessage, 1)
+	alice.incomingPipe = lobbyPipe
+	engineHandleNick(e, alice, nickRequest{nickname: "Carol", forwarded: true})
+	approved := (<-lobbyPipe).payload.(nickRequest)
+
+	// The client quits before the lobby sees the approval, which the lobby
+	// then hands back to the engine.
+	alice.incomingPipe = e.incomingPipe
+	engineHandleQuit(e, alice, nil)
+	engineHandleNick(e, alice, approved)
+
+	for _, nick := range []string{"Alice", "Carol"} {
+		if !e.nicknameGenerator.Reserve(nick) {
+			t.Errorf("%s is still reserved after the client quit", nick)
+		}
+	}
+}
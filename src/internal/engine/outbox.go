@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"encoding/json"
+	"sync"
+
+	"internal/log"
+)
+
+// outboxLimit is the number of undelivered frames a client may accumulate
+// before it is considered too slow to keep up and is cut off.
+const outboxLimit = 128
+
+type OutboxStatus int
+
+const (
+	OutboxOpen OutboxStatus = iota
+	OutboxClosed
+	OutboxLagging
+)
+
+type frame struct {
+	data  []byte
+	state bool
+}
+
+// outbox queues marshalled frames for a client's writer without ever blocking
+// the sender. Only the newest state frame is kept, since each one supersedes
+// the last; a client whose queue overflows regardless is marked as lagging
+// and receives nothing more until it resumes.
+type outbox struct {
+	sync.Mutex
+	frames  []frame
+	pending chan struct{}
+	status  OutboxStatus
+}
+
+func newOutbox() *outbox {
+	return &outbox{
+		pending: make(chan struct{}, 1),
+	}
+}
+
+func (o *outbox) push(data []byte, state bool) {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.status != OutboxOpen {
+		return
+	}
+
+	if state {
+		for i := range o.frames {
+			if o.frames[i].state {
+				o.frames = append(o.frames[:i], o.frames[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(o.frames) >= outboxLimit {
+		o.frames = nil
+		o.status = OutboxLagging
+	} else {
+		o.frames = append(o.frames, frame{data, state})
+	}
+	o.notify()
+}
+
+func (o *outbox) notify() {
+	select {
+	case o.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (o *outbox) close() {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.status == OutboxOpen {
+		o.status = OutboxClosed
+	}
+	o.notify()
+}
+
+// reset discards anything queued for a previous connection.
+func (o *outbox) reset() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.frames = nil
+	if o.status == OutboxLagging {
+		o.status = OutboxOpen
+	}
+}
+
+func (o *outbox) drain() ([][]byte, OutboxStatus) {
+	o.Lock()
+	defer o.Unlock()
+
+	data := make([][]byte, len(o.frames))
+	for i, frame := range o.frames {
+		data[i] = frame.data
+	}
+	o.frames = nil
+	return data, o.status
+}
+
+// Send queues a message for the client. The message is marshalled
+// immediately, so it must only be called by the goroutine that owns any state
+// the message refers to.
+func (c *Client) Send(message OutgoingMessage) {
+	_, state := message.(clientStateMessage)
+	c.outbox.push(marshal(message), state)
+}
+
+// Pending is signalled whenever frames are queued for the client, or its
+// outbox closes.
+func (c *Client) Pending() <-chan struct{} {
+	return c.outbox.pending
+}
+
+// Drain returns the frames queued for the client, along with whether the
+// writer should keep going.
+func (c *Client) Drain() ([][]byte, OutboxStatus) {
+	return c.outbox.drain()
+}
+
+func marshal(message OutgoingMessage) []byte {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Fields{"error": err}.Panic("couldn't marshal outgoing message")
+	}
+	return data
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOutboxKeepsNewestState(t *testing.T) {
+	o := newOutbox()
+	o.push([]byte("state 1"), true)
+	o.push([]byte("chat 1"), false)
+	o.push([]byte("state 2"), true)
+	o.push([]byte("chat 2"), false)
+	o.push([]byte("state 3"), true)
+
+	frames, status := o.drain()
+	if status != OutboxOpen {
+		t.Errorf("status is %d, want open", status)
+	}
+
+	want := []string{"chat 1", "chat 2", "state 3"}
+	if len(frames) != len(want) {
+		t.Fatalf("drained %q, want %q", frames, want)
+	}
+	for i := range want {
+		if string(frames[i]) != want[i] {
+			t.Fatalf("drained %q, want %q", frames, want)
+		}
+	}
+}
+
+func TestOutboxLagsPastLimit(t *testing.T) {
+	o := newOutbox()
+	for i := 0; i < outboxLimit; i++ {
+		o.push([]byte(fmt.Sprintf("chat %d", i)), false)
+	}
+	if o.status != OutboxOpen {
+		t.Fatalf("status is %d after %d frames, want open", o.status, outboxLimit)
+	}
+
+	o.push([]byte("one too many"), false)
+	frames, status := o.drain()
+	if status != OutboxLagging {
+		t.Errorf("status is %d, want lagging", status)
+	}
+	if len(frames) != 0 {
+		t.Errorf("lagging outbox kept %d frames", len(frames))
+	}
+
+	o.push([]byte("ignored"), true)
+	if frames, _ := o.drain(); len(frames) != 0 {
+		t.Errorf("lagging outbox accepted %q", frames)
+	}
+}
+
+func TestOutboxReset(t *testing.T) {
+	o := newOutbox()
+	for i := 0; i <= outboxLimit; i++ {
+		o.push([]byte("chat"), false)
+	}
+
+	o.reset()
+	if frames, status := o.drain(); status != OutboxOpen || len(frames) != 0 {
+		t.Fatalf("reset left %d frames and status %d, want none and open", len(frames), status)
+	}
+
+	o.push([]byte("stale"), false)
+	o.reset()
+	o.push([]byte("fresh"), true)
+	frames, _ := o.drain()
+	if len(frames) != 1 || string(frames[0]) != "fresh" {
+		t.Errorf("drained %q after reset, want only the fresh frame", frames)
+	}
+}
+
+func TestOutboxResetKeepsClosed(t *testing.T) {
+	o := newOutbox()
+	o.close()
+	o.reset()
+	if _, status := o.drain(); status != OutboxClosed {
+		t.Errorf("status is %d after reset, want closed", status)
+	}
+}
+
+func TestBroadcastStateDoesNotBlock(t *testing.T) {
+	e := New()
+	l := newTestLobby(e, "stuck")
+
+	stuck := newTestClient("s1", "Stuck")
+	stuck.Lobby = l
+	l.Clients[stuck] = &clientData{}
+
+	watcher := newTestClient("w1", "Watcher")
+	watcher.Lobby = l
+	l.Spectators[watcher] = struct{}{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10*outboxLimit; i++ {
+			l.broadcastState(fmt.Sprintf("update %d", i))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcastState blocked on clients that never read")
+	}
+
+	for _, client := range []*Client{stuck, watcher} {
+		frames, status := client.Drain()
+		if status != OutboxOpen {
+			t.Errorf("%s's status is %d, want open", client.Nickname, status)
+		}
+		if len(frames) != 1 || !bytes.Contains(frames[0], []byte(fmt.Sprintf("update %d", 10*outboxLimit-1))) {
+			t.Errorf("%s was left %d frames, want only the newest state", client.Nickname, len(frames))
+		}
+	}
+
+	// A client that falls behind on other messages is cut off rather than
+	// holding up the lobby.
+	for i := 0; i <= outboxLimit; i++ {
+		stuck.Send(clientChatMessage{FromID: watcher.ID, From: watcher.Nickname, Text: "hello"})
+	}
+
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		l.broadcastState("after lagging")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcastState blocked on a lagging client")
+	}
+
+	if _, status := stuck.Drain(); status != OutboxLagging {
+		t.Errorf("stuck client's status is %d, want lagging", status)
+	}
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+)
+
+const passphraseSaltLength = 16
+
+type passphrase struct {
+	salt []byte
+	hash []byte
+}
+
+func newPassphrase(plain string) (*passphrase, error) {
+	salt := make([]byte, passphraseSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return &passphrase{
+		salt: salt,
+		hash: hashPassphrase(salt, plain),
+	}, nil
+}
+
+func hashPassphrase(salt []byte, plain string) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(plain))
+	return mac.Sum(nil)
+}
+
+func (p *passphrase) matches(plain string) bool {
+	return hmac.Equal(p.hash, hashPassphrase(p.salt, plain))
+}
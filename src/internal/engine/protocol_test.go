@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"internal/grid"
+	"internal/protocol"
+	"internal/store"
+)
+
+// roundTrip checks that protocol.DecodeMessage reads an engine message as a
+// message of the same type as want, and that every field the engine sent
+// survives decoding.
+func roundTrip(t *testing.T, message OutgoingMessage, want protocol.Message) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("couldn't marshal %T: %s", message, err)
+	}
+
+	decoded, err := protocol.DecodeMessage(data)
+	if err != nil {
+		t.Fatalf("couldn't decode %s: %s", data, err)
+	}
+	if reflect.TypeOf(decoded) != reflect.TypeOf(want) {
+		t.Fatalf("%T decoded as %T, want %T", message, decoded, want)
+	}
+
+	redone, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("couldn't marshal %T: %s", decoded, err)
+	}
+
+	var sent, read map[string]interface{}
+	if err = json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(redone, &read); err != nil {
+		t.Fatal(err)
+	}
+	read["type"] = decoded.Type()
+
+	if !reflect.DeepEqual(sent, read) {
+		t.Errorf("%T doesn't survive decoding:\nsent %s\nread %s", message, data, redone)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	e := New()
+	l := newTestLobby(e, "round trip")
+	l.State = protocol.StateBetweenGames
+	l.asyncTimestamp = time.Now().Add(time.Minute)
+	l.Rated = true
+	l.Grid = grid.GenerateFromSeed(l.cubes, 1)
+
+	alice := newTestClient("a1", "Alice")
+	alice.AccountID = "account-a"
+	alice.Rating = 1516
+	alice.Lobby = l
+	bob := newTestClient("b2", "Bob")
+	bob.Spectator = true
+	bob.Lobby = l
+
+	result := &gameResult{
+		Score: 3,
+		Words: []scoredWord{{Word: "TEA", Points: 1, Found: true}, {Word: "TEAS", Points: 2, Found: true}},
+	}
+	l.Clients[alice] = &clientData{Readied: true, Score: 3, WordsFound: 2, PreviousResult: result}
+	l.Spectators[bob] = struct{}{}
+	l.owner = alice
+	l.MasterSolution = result
+	l.GameID = "game"
+
+	percentile := 75.0
+	l.Challenge = &challengeRun{Day: "2026-10-18", Finished: true, Rank: 2, Percentile: &percentile, Players: 5}
+
+	roundTrip(t, clientStateMessage{
+		Message:     "Game has concluded",
+		ResumeToken: "token",
+		Client:      alice,
+	}, &protocol.State{})
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	seconds := 12.5
+	game := store.Game{
+		ID:         "game",
+		Lobby:      "lobby",
+		Language:   "en",
+		Dictionary: "english",
+		Size:       4,
+		Grid:       [][]string{{"A", "B"}, {"C", "D"}},
+		Seed:       42,
+		Settings:   store.Settings{GameDuration: 90, CountdownDuration: 5, IntermissionDuration: 30, MinimumPlayers: 2},
+		StartedAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		EndedAt:    time.Date(2026, 10, 18, 12, 1, 30, 0, time.UTC),
+		Players: []store.Player{{
+			ID:           "a1",
+			AccountID:    "account-a",
+			Nickname:     "Alice",
+			Score:        1,
+			Words:        []store.Word{{Word: "CAB", Points: 1}},
+			Rating:       1516,
+			RatingChange: 16,
+		}},
+	}
+	event := store.Event{Offset: 1500, Type: store.EventWord, PlayerID: "a1", Nickname: "Alice", Word: "CAB", Status: protocol.WordValid}
+
+	cases := []struct {
+		message OutgoingMessage
+		want    protocol.Message
+	}{
+		{clientErrorMessage{Command: "join", Code: protocol.ErrorForbidden, Message: "No"}, &protocol.Error{}},
+		{clientWordMessage{Word: "CAB", Status: protocol.WordValid, Path: []grid.Cell{{Row: 1, Column: 0}, {Row: 0, Column: 0}}}, &protocol.Word{}},
+		{clientChatMessage{FromID: "a1", From: "Alice", Text: "hello", Spectator: true}, &protocol.Chat{}},
+		{clientLobbyListMessage{Lobbies: []LobbySummary{{
+			Name:             "lobby",
+			State:            protocol.StateInGame,
+			Players:          2,
+			Spectators:       1,
+			SecondsRemaining: &seconds,
+			Language:         "en",
+			Size:             4,
+			Dictionary:       "english",
+			Settings:         defaultLobbySettings(),
+			Rated:            true,
+		}}}, &protocol.LobbyList{}},
+		{clientReplayMessage{GameID: game.ID, Speed: 2, Game: &game}, &protocol.Replay{}},
+		{clientReplayEventMessage{GameID: game.ID, Event: event}, &protocol.ReplayEvent{}},
+		{clientReplayEndMessage{GameID: game.ID}, &protocol.ReplayEnd{}},
+	}
+
+	for _, c := range cases {
+		roundTrip(t, c.message, c.want)
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"internal/log"
+	"internal/protocol"
+	"internal/rating"
+	"internal/store"
+)
+
+// SetRatings rates every finished game between signed-in players in t.
+func (e *Engine) SetRatings(t *rating.Table) {
+	e.ratings = t
+}
+
+func admitRated(client *Client, lobby *lobby) bool {
+	if !lobby.Rated || client.AccountID != "" {
+		return true
+	}
+
+	client.Send(clientErrorMessage{
+		Command: "join",
+		Code:    protocol.ErrorForbidden,
+		Message: "That lobby is rated; sign in to join it, or spectate instead",
+	})
+
+	log.Fields{"client": client.Nickname, "lobby": lobby.Name}.Debug("anonymous client tried to join a rated lobby")
+	return false
+}
+
+// rateGame notes new ratings on a finished game without recording them.
+func (l *lobby) rateGame(game *store.Game) {
+	if l.ratings != nil {
+		l.ratings.Rate(game)
+	}
+}
+
+// recordRatings records the ratings noted on a game that has been kept, and
+// updates the clients that played it, in the same order as its players.
+func (l *lobby) recordRatings(game *store.Game, clients []*Client) {
+	if l.ratings == nil {
+		return
+	}
+
+	l.ratings.Record(game)
+	for i, player := range game.Players {
+		if player.Rating != 0 {
+			clients[i].Rating = player.Rating
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"encoding/json"
+	"time"
+
+	"internal/log"
+	"internal/protocol"
+	"internal/store"
+)
+
+// maxReplaySpeed bounds how many times faster than real time a game may be
+// replayed.
+const maxReplaySpeed = 32
+
+type replayRequest struct {
+	gameID string
+	speed  float64
+}
+
+type clientReplayMessage struct {
+	GameID string      `json:"gameId"`
+	Speed  float64     `json:"speed"`
+	Game   *store.Game `json:"game"`
+}
+
+type clientReplayEventMessage struct {
+	GameID string      `json:"gameId"`
+	Event  store.Event `json:"event"`
+}
+
+type clientReplayEndMessage struct {
+	GameID string `json:"gameId"`
+}
+
+// Replay streams a finished game's timeline to the client, speed times faster
+// than it was played; zero replays it in real time. Joining or spectating a
+// lobby, or starting another replay, stops it.
+func (c *Client) Replay(gameID string, speed float64) {
+	c.incomingPipe <- incomingMessage{
+		what:   messageTypeReplay,
+		client: c,
+		payload: replayRequest{
+			gameID: gameID,
+			speed:  speed,
+		},
+	}
+}
+
+func engineHandleReplay(e *Engine, client *Client, data interface{}) {
+	request := data.(replayRequest)
+
+	if request.speed == 0 {
+		request.speed = 1
+	}
+	if request.speed < 1 || request.speed > maxReplaySpeed {
+		client.Send(clientErrorMessage{
+			Command: "replay",
+			Code:    protocol.ErrorBadArgument,
+			Message: "Replay speed must be between 1 and 32",
+		})
+		return
+	}
+
+	if e.store == nil {
+		client.Send(clientErrorMessage{
+			Command: "replay",
+			Code:    protocol.ErrorNotFound,
+			Message: "This server does not keep game history",
+		})
+		return
+	}
+
+	stopReplay(client)
+	stop := make(chan struct{})
+	client.replayStop = stop
+	go replay(e.store, client, request, stop)
+
+	log.Fields{"client": client.Nickname, "game": request.gameID, "speed": request.speed}.Debug("client started a replay")
+}
+
+func lobbyHandleReplay(l *lobby, client *Client, _ interface{}) {
+	client.Send(clientErrorMessage{
+		Command: "replay",
+		Code:    protocol.ErrorAlreadyInLobby,
+		Message: "Leave the lobby to watch a replay",
+	})
+
+	log.Fields{"lobby": l.Name, "client": client.Nickname}.Debug("client tried to watch a replay, but is in a lobby")
+}
+
+// stopReplay stops the replay the client is watching, if any. Only the
+// engine, which owns clients outside lobbies, starts or stops replays.
+func stopReplay(client *Client) {
+	if client.replayStop != nil {
+		close(client.replayStop)
+		client.replayStop = nil
+	}
+}
+
+// replay runs on its own goroutine. Its messages don't refer to the client,
+// so they may be sent without synchronizing with the client's owner.
+func replay(s store.Store, client *Client, request replayRequest, stop <-chan struct{}) {
+	game, err := s.Game(request.gameID)
+	if err == store.ErrNotFound {
+		client.Send(clientErrorMessage{
+			Command: "replay",
+			Code:    protocol.ErrorNotFound,
+			Message: "There is no game with that ID",
+		})
+		return
+	} else if err != nil {
+		log.Fields{"game": request.gameID, "error": err}.Error("couldn't read game to replay")
+		client.Send(clientErrorMessage{
+			Command: "replay",
+			Code:    protocol.ErrorInternal,
+			Message: "Couldn't load that game; please try again",
+		})
+		return
+	}
+
+	select {
+	case <-stop:
+		return
+	default:
+	}
+
+	events := game.Events
+	game.Events = nil
+	client.Send(clientReplayMessage{
+		GameID: game.ID,
+		Speed:  request.speed,
+		Game:   &game,
+	})
+
+	start := time.Now()
+	for _, event := range events {
+		due := start.Add(time.Duration(float64(event.Offset) * float64(time.Millisecond) / request.speed))
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Until(due)):
+		}
+
+		client.Send(clientReplayEventMessage{
+			GameID: game.ID,
+			Event:  event,
+		})
+	}
+
+	client.Send(clientReplayEndMessage{
+		GameID: game.ID,
+	})
+}
+
+func (m clientReplayMessage) MarshalJSON() ([]byte, error) {
+	type Alias clientReplayMessage
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Alias
+	}{
+		Type:  "replay",
+		Alias: (Alias)(m),
+	})
+}
+
+func (m clientReplayEventMessage) MarshalJSON() ([]byte, error) {
+	type Alias clientReplayEventMessage
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Alias
+	}{
+		Type:  "replayEvent",
+		Alias: (Alias)(m),
+	})
+}
+
+func (m clientReplayEndMessage) MarshalJSON() ([]byte, error) {
+	type Alias clientReplayEndMessage
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Alias
+	}{
+		Type:  "replayEnd",
+		Alias: (Alias)(m),
+	})
+}
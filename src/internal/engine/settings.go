@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultGameDuration         = 3 * time.Minute
+	defaultCountdownDuration    = 5 * time.Second
+	defaultIntermissionDuration = 1 * time.Minute
+	defaultMinimumPlayers       = 2
+)
+
+type durationBounds struct {
+	name     string
+	min, max time.Duration
+}
+
+var (
+	gameDurationBounds         = durationBounds{"Game duration", 30 * time.Second, 10 * time.Minute}
+	countdownDurationBounds    = durationBounds{"Countdown duration", 3 * time.Second, 30 * time.Second}
+	intermissionDurationBounds = durationBounds{"Intermission duration", 10 * time.Second, 5 * time.Minute}
+)
+
+type countBounds struct {
+	name     string
+	min, max int
+}
+
+var minimumPlayersBounds = countBounds{"Minimum players", 1, 16}
+
+type lobbySettings struct {
+	GameDuration         time.Duration
+	CountdownDuration    time.Duration
+	IntermissionDuration time.Duration
+	MinimumPlayers       int
+}
+
+type SettingsRequest struct {
+	GameDuration         time.Duration
+	CountdownDuration    time.Duration
+	IntermissionDuration time.Duration
+	MinimumPlayers       int
+}
+
+func defaultLobbySettings() lobbySettings {
+	return lobbySettings{
+		GameDuration:         defaultGameDuration,
+		CountdownDuration:    defaultCountdownDuration,
+		IntermissionDuration: defaultIntermissionDuration,
+		MinimumPlayers:       defaultMinimumPlayers,
+	}
+}
+
+func (s lobbySettings) apply(request SettingsRequest) (lobbySettings, error) {
+	if err := applyDuration(&s.GameDuration, request.GameDuration, gameDurationBounds); err != nil {
+		return s, err
+	}
+
+	if err := applyDuration(&s.CountdownDuration, request.CountdownDuration, countdownDurationBounds); err != nil {
+		return s, err
+	}
+
+	if err := applyDuration(&s.IntermissionDuration, request.IntermissionDuration, intermissionDurationBounds); err != nil {
+		return s, err
+	}
+
+	if err := applyCount(&s.MinimumPlayers, request.MinimumPlayers, minimumPlayersBounds); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+func applyDuration(setting *time.Duration, requested time.Duration, bounds durationBounds) error {
+	if requested == 0 {
+		return nil
+	}
+
+	if requested < bounds.min || requested > bounds.max {
+		return fmt.Errorf("%s must be between %d and %d seconds", bounds.name, bounds.min/time.Second, bounds.max/time.Second)
+	}
+
+	*setting = requested
+	return nil
+}
+
+func applyCount(setting *int, requested int, bounds countBounds) error {
+	if requested == 0 {
+		return nil
+	}
+
+	if requested < bounds.min || requested > bounds.max {
+		return fmt.Errorf("%s must be between %d and %d", bounds.name, bounds.min, bounds.max)
+	}
+
+	*setting = requested
+	return nil
+}
+
+func (s lobbySettings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		GameDuration         int `json:"gameDuration"`
+		CountdownDuration    int `json:"countdownDuration"`
+		IntermissionDuration int `json:"intermissionDuration"`
+		MinimumPlayers       int `json:"minimumPlayers"`
+	}{
+		GameDuration:         int(s.GameDuration / time.Second),
+		CountdownDuration:    int(s.CountdownDuration / time.Second),
+		IntermissionDuration: int(s.IntermissionDuration / time.Second),
+		MinimumPlayers:       s.MinimumPlayers,
+	})
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"internal/log"
+	"internal/protocol"
+)
+
+type shutdownRequest struct {
+	deadline time.Time
+	reply    chan *sync.WaitGroup
+	drained  *sync.WaitGroup
+}
+
+// Shutdown stops new lobbies from being created and new games from starting,
+// and warns every lobby that the server is going away. Games in progress run
+// until they end or the deadline passes, whichever is sooner; Shutdown
+// returns once no game is being played, or at the deadline if a lobby fails
+// to report back, and every finished game has been written to the store.
+func (e *Engine) Shutdown(deadline time.Time) {
+	reply := make(chan *sync.WaitGroup, 1)
+	e.incomingPipe <- incomingMessage{
+		what: messageTypeShutdown,
+		payload: shutdownRequest{
+			deadline: deadline,
+			reply:    reply,
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		(<-reply).Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info("all games have concluded")
+	case <-time.After(time.Until(deadline) + time.Second):
+		log.Error("timed out waiting for games to conclude")
+	}
+
+	e.flushHistory()
+}
+
+func engineHandleShutdown(e *Engine, _ *Client, data interface{}) {
+	request := data.(shutdownRequest)
+	e.draining = true
+
+	request.drained = &sync.WaitGroup{}
+	request.drained.Add(len(e.lobbies))
+	for _, lobby := range e.lobbies {
+		lobby.incomingPipe <- incomingMessage{
+			what:    messageTypeShutdown,
+			payload: request,
+		}
+	}
+	request.reply <- request.drained
+
+	log.Fields{"lobbies": len(e.lobbies), "deadline": request.deadline}.Info("engine is draining")
+}
+
+func lobbyHandleShutdown(l *lobby, _ *Client, data interface{}) {
+	request := data.(shutdownRequest)
+	l.draining = request.drained
+
+	if l.State != protocol.StateInGame {
+		l.broadcastState("The server is shutting down; no new games will start")
+		return
+	}
+
+	if l.asyncTimestamp.After(request.deadline) {
+		l.resetAsyncInterrupt(time.Until(request.deadline))
+	}
+	l.broadcastState("The server is shutting down; this will be the last game")
+}
+
+// checkDrained tells the engine, once, that a draining lobby has no game in
+// progress.
+func (l *lobby) checkDrained() {
+	if l.draining == nil || l.drained || l.State == protocol.StateInGame {
+		return
+	}
+
+	l.drained = true
+	l.draining.Done()
+	log.Fields{"lobby": l.Name}.Debug("lobby has drained")
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"sort"
+	"time"
+)
+
+type LobbySummary struct {
+	Name             string        `json:"name"`
+	State            string        `json:"state"`
+	Players          int           `json:"players"`
+	Spectators       int           `json:"spectators"`
+	SecondsRemaining *float64      `json:"secondsRemaining,omitempty"`
+	Language         string        `json:"language"`
+	Size             int           `json:"size"`
+	Dictionary       string        `json:"dictionary"`
+	Settings         lobbySettings `json:"settings"`
+	Rated            bool          `json:"rated"`
+
+	deadline time.Time
+}
+
+// publishSummary records a snapshot of the lobby that other goroutines may
+// read without synchronizing with the lobby's event loop.
+func (l *lobby) publishSummary() {
+	l.summary.Store(LobbySummary{
+		Name:       l.Name,
+		State:      l.State,
+		Players:    len(l.Clients),
+		Spectators: len(l.Spectators),
+		Language:   l.Language.Code,
+		Size:       l.Size,
+		Dictionary: l.Dictionary.Name,
+		Settings:   l.Settings,
+		Rated:      l.Rated,
+		deadline:   l.asyncTimestamp,
+	})
+}
+
+func (l *lobby) loadSummary() LobbySummary {
+	summary := l.summary.Load().(LobbySummary)
+	summary.SecondsRemaining = secondsUntil(summary.deadline)
+	return summary
+}
+
+func secondsUntil(deadline time.Time) *float64 {
+	remaining := (float64)(deadline.Sub(time.Now())) / (float64)(time.Second)
+	if remaining < 0 {
+		return nil
+	}
+	return &remaining
+}
+
+func (e *Engine) publicLobbies() []LobbySummary {
+	summaries := []LobbySummary{}
+	for _, lobby := range e.lobbies {
+		if lobby.passphrase == nil && lobby.Challenge == nil {
+			summaries = append(summaries, lobby.loadSummary())
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+func (e *Engine) Lobbies() []LobbySummary {
+	reply := make(chan []LobbySummary, 1)
+	e.incomingPipe <- incomingMessage{
+		what:    messageTypeList,
+		payload: reply,
+	}
+	return <-reply
+}
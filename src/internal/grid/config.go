@@ -2,31 +2,37 @@ package grid
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
-	"path"
-
-	"internal/log"
-	"internal/wordlist"
+	"math"
 )
 
-type cubeSet [4 * 4][6]string
+type CubeSet [][]string
 
-var list wordlist.Wordlist
-var cubes cubeSet
+func LoadCubes(path string) (CubeSet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-func init() {
-	var err error
-	if list, err = wordlist.FromFile(path.Join("config", "words.list")); err != nil {
-		log.Fields{"error": err}.Panic("couldn't load wordlist")
+	var cubes CubeSet
+	if err = json.Unmarshal(data, &cubes); err != nil {
+		return nil, err
 	}
 
-	var cubeData []byte
-	if cubeData, err = ioutil.ReadFile(path.Join("config", "cubes.json")); err != nil {
-		log.Fields{"error": err}.Panic("couldn't read cubes")
+	if size := cubes.Size(); size == 0 || size*size != len(cubes) {
+		return nil, fmt.Errorf("%d cubes cannot fill a square grid", len(cubes))
 	}
 
-	if err = json.Unmarshal(cubeData, &cubes); err != nil {
-		log.Fields{"error": err}.Panic("couldn't parse cubes")
+	for i, cube := range cubes {
+		if len(cube) == 0 {
+			return nil, fmt.Errorf("cube %d has no faces", i)
+		}
 	}
 
+	return cubes, nil
+}
+
+func (c CubeSet) Size() int {
+	return int(math.Sqrt(float64(len(c))))
 }
@@ -5,26 +5,45 @@ import (
 	"time"
 )
 
-type Grid [4][4]string
+const (
+	SizeStandard = 4
+	SizeBig      = 5
+	SizeSuperBig = 6
+)
+
+type Grid [][]string
 
 var r *rand.Rand = rand.New(rand.NewSource(time.Now().Unix()))
 
-func Generate(seedOutput *int64) Grid {
+func New(size int) Grid {
+	grid := make(Grid, size)
+	for i := range grid {
+		grid[i] = make([]string, size)
+	}
+	return grid
+}
+
+func Generate(cubes CubeSet, seedOutput *int64) Grid {
 	seed := r.Int63()
-	grid := GenerateFromSeed(seed)
+	grid := GenerateFromSeed(cubes, seed)
 	if seedOutput != nil {
 		*seedOutput = seed
 	}
 	return grid
 }
 
-func GenerateFromSeed(seed int64) Grid {
+func GenerateFromSeed(cubes CubeSet, seed int64) Grid {
+	size := cubes.Size()
 	rand := rand.New(rand.NewSource(seed))
-	grid := Grid{}
+	grid := New(size)
 	i := 0
-	for _, c := range rand.Perm(4 * 4) {
-		grid[i%4][i/4] = cubes[c][rand.Intn(len(cubes[c]))]
+	for _, c := range rand.Perm(size * size) {
+		grid[i%size][i/size] = cubes[c][rand.Intn(len(cubes[c]))]
 		i++
 	}
 	return grid
 }
+
+func (g Grid) Size() int {
+	return len(g)
+}
@@ -1,11 +1,23 @@
 package grid
 
-import "strings"
+import (
+	"unicode/utf8"
+
+	"internal/dictionary"
+)
 
 var scoreTable = [18]int{0, 0, 0, 1, 1, 2, 3, 5, 11, 18, 20, 22, 24, 26, 28, 30, 32, 34}
 
-func (g Grid) Score(lists [][]string) ([]int, [][]int, []string, int, []int) {
-	solution := g.Solve()
+func Points(word string) int {
+	length := utf8.RuneCountInString(word)
+	if length >= len(scoreTable) {
+		return scoreTable[len(scoreTable)-1] + 2*(length-len(scoreTable)+1)
+	}
+	return scoreTable[length]
+}
+
+func (g Grid) Score(d *dictionary.Dictionary, lists [][]string) ([]int, [][]int, []string, int, []int) {
+	solution := g.Solve(d)
 	solutionSet := map[string]struct{}{}
 
 	for _, word := range solution {
@@ -16,7 +28,7 @@ func (g Grid) Score(lists [][]string) ([]int, [][]int, []string, int, []int) {
 
 	for _, list := range lists {
 		for _, word := range list {
-			word = strings.ToUpper(word)
+			word = d.Fold(word)
 			if _, ok := solutionSet[word]; ok {
 				wordCounts[word]++
 			}
@@ -29,7 +41,7 @@ func (g Grid) Score(lists [][]string) ([]int, [][]int, []string, int, []int) {
 		if wordCounts[word] > 0 {
 			masterScore[i] = 0
 		} else {
-			masterScore[i] = scoreTable[len(word)]
+			masterScore[i] = Points(word)
 		}
 		masterTotal += masterScore[i]
 	}
@@ -41,9 +53,9 @@ func (g Grid) Score(lists [][]string) ([]int, [][]int, []string, int, []int) {
 		scores[i] = make([]int, len(list))
 
 		for j, word := range list {
-			word = strings.ToUpper(word)
+			word = d.Fold(word)
 			if wordCounts[word] == 1 {
-				scores[i][j] = scoreTable[len(word)]
+				scores[i][j] = Points(word)
 			} else if _, ok := solutionSet[word]; !ok {
 				scores[i][j] = -1
 			} else {
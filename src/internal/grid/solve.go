@@ -2,66 +2,91 @@ package grid
 
 import (
 	"sort"
+	"unicode/utf8"
 
+	"internal/dictionary"
 	"internal/wordlist"
 )
 
-type solveState struct {
-	i, j, mask int
-	query      string
+const MinimumWordLength = 3
+
+type solver struct {
+	dictionary *dictionary.Dictionary
+	faces      [][]string
+	found      map[string]bool
+	query      []byte
 }
-type markTable map[solveState]bool
-
-func (g Grid) Solve() []string {
-	found := map[string]bool{}
-	visited := markTable{}
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			g.recursiveSolve(visited, found, i, j, strike(0, i, j), list.NewSearch(g[i][j]))
+
+func (g Grid) Solve(d *dictionary.Dictionary) []string {
+	s := solver{
+		dictionary: d,
+		faces:      g.fold(d),
+		found:      map[string]bool{},
+	}
+
+	for i := range g {
+		for j := range g[i] {
+			s.recursiveSolve(i, j, 0, wordlist.Root)
 		}
 	}
-	result := []string{}
-	for key := range found {
+
+	result := make([]string, 0, len(s.found))
+	for key := range s.found {
 		result = append(result, key)
 	}
 	sort.Strings(result)
 	return result
 }
 
-func (g Grid) recursiveSolve(visited markTable, found map[string]bool, i, j, mask int, search wordlist.Search) {
-	tuple := solveState{i, j, mask, search.Query}
-	if visited[tuple] {
+func (s *solver) recursiveSolve(i, j int, mask uint64, node wordlist.Node) {
+	node, ok := s.dictionary.Walk(node, s.faces[i][j])
+	if !ok {
 		return
 	}
-	visited[tuple] = true
 
-	if len(search.Query) >= 3 && search.ExactMatch() {
-		found[search.Query] = true
+	mask = strike(mask, len(s.faces), i, j)
+	s.query = append(s.query, s.faces[i][j]...)
+
+	if s.dictionary.Terminal(node) && utf8.RuneCount(s.query) >= MinimumWordLength {
+		s.found[string(s.query)] = true
 	}
 
-	if !search.Empty() {
+	if !s.dictionary.Leaf(node) {
 		for p := i - 1; p <= i+1; p++ {
-			if !(0 <= p && p < 4) {
+			if !(0 <= p && p < len(s.faces)) {
 				continue
 			}
 
 			for q := j - 1; q <= j+1; q++ {
-				if !(0 <= q && q < 4) {
+				if !(0 <= q && q < len(s.faces)) {
 					continue
 				}
 
-				if !struck(mask, p, q) {
-					g.recursiveSolve(visited, found, p, q, strike(mask, p, q), search.Narrow(g[p][q]))
+				if !struck(mask, len(s.faces), p, q) {
+					s.recursiveSolve(p, q, mask, node)
 				}
 			}
 		}
 	}
+
+	s.query = s.query[:len(s.query)-len(s.faces[i][j])]
+}
+
+func (g Grid) fold(d *dictionary.Dictionary) [][]string {
+	faces := make([][]string, len(g))
+	for i, row := range g {
+		faces[i] = make([]string, len(row))
+		for j, face := range row {
+			faces[i][j] = d.Fold(face)
+		}
+	}
+	return faces
 }
 
-func strike(m, i, j int) int {
-	return m | (1 << uint(i*4+j))
+func strike(m uint64, size, i, j int) uint64 {
+	return m | (1 << uint(i*size+j))
 }
 
-func struck(m, i, j int) bool {
-	return (m & (1 << uint(i*4+j))) != 0
+func struck(m uint64, size, i, j int) bool {
+	return (m & (1 << uint(i*size+j))) != 0
 }
@@ -0,0 +1,207 @@
+package grid
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"internal/dictionary"
+	"internal/wordlist"
+)
+
+// benchmarkLanguage holds the English pack, relative to this package.
+var benchmarkLanguage = path.Join("..", "..", "..", "config", "languages", "en")
+
+// testDictionary returns a dictionary of words, folded to upper case as the
+// English pack folds them.
+func testDictionary(words ...string) *dictionary.Dictionary {
+	list := make(wordlist.Wordlist, len(words))
+	for i, word := range words {
+		list[i] = strings.ToUpper(word)
+	}
+	sort.Strings(list)
+	return dictionary.New("test", "en", list, strings.ToUpper)
+}
+
+// board returns a grid with one face per letter of each row, except that "q"
+// stands for the "Qu" face.
+func board(rows ...string) Grid {
+	g := New(len(rows))
+	for i, row := range rows {
+		for j, letter := range row {
+			g[i][j] = strings.ToUpper(string(letter))
+			if letter == 'q' {
+				g[i][j] = "Qu"
+			}
+		}
+	}
+	return g
+}
+
+func TestSolve(t *testing.T) {
+	d := testDictionary("at", "cast", "cat", "cats", "ding", "doc", "ere", "qat", "qua", "quest", "quiet", "tats")
+	g := board(
+		"cats",
+		"oqer",
+		"ding",
+		"xyzw",
+	)
+
+	// AT is too short; CAST isn't connected; ERE and TATS would use a cube
+	// twice; QAT needs a Q without its U.
+	want := []string{"CAT", "CATS", "DING", "DOC", "QUA", "QUEST", "QUIET"}
+	if got := g.Solve(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("solved %q, want %q", got, want)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	d := testDictionary("cat")
+	g := board(
+		"cats",
+		"oqer",
+		"ding",
+		"xyzw",
+	)
+
+	cases := []struct {
+		word string
+		path []Cell
+	}{
+		{"quiet", []Cell{{1, 1}, {2, 1}, {1, 2}, {0, 2}}},
+		{"QUEST", []Cell{{1, 1}, {1, 2}, {0, 3}, {0, 2}}},
+		{"doc", []Cell{{2, 0}, {1, 0}, {0, 0}}},
+		{"qat", nil},
+		{"ere", nil},
+		{"tats", nil},
+		{"cast", nil},
+		{"zzz", nil},
+	}
+
+	for _, c := range cases {
+		if path := g.Trace(d, c.word); !reflect.DeepEqual(path, c.path) {
+			t.Errorf("traced %q along %v, want %v", c.word, path, c.path)
+		}
+	}
+}
+
+func TestSolveLargerGrids(t *testing.T) {
+	d := testDictionary("and", "ede", "end", "fen", "nab", "quad")
+
+	big := board(
+		"xxxxx",
+		"xxxxx",
+		"xxxxb",
+		"xxxan",
+		"xxqde",
+	)
+	if got, want := big.Solve(d), []string{"AND", "END", "NAB", "QUAD"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("solved 5x5 grid to %q, want %q", got, want)
+	}
+
+	superBig := board(
+		"fxxxxx",
+		"xexxxx",
+		"xxnxxx",
+		"xxxxxx",
+		"xxxxnx",
+		"xxxxde",
+	)
+	if got, want := superBig.Solve(d), []string{"END", "FEN"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("solved 6x6 grid to %q, want %q", got, want)
+	}
+	if path, want := superBig.Trace(d, "end"), []Cell{{5, 5}, {4, 4}, {5, 4}}; !reflect.DeepEqual(path, want) {
+		t.Errorf("traced END along %v, want %v", path, want)
+	}
+	if path := superBig.Trace(d, "ede"); path != nil {
+		t.Errorf("traced EDE along %v, reusing a cube", path)
+	}
+}
+
+// TestSolveMatchesTrace checks Solve against tracing every dictionary word on
+// generated boards of each size.
+func TestSolveMatchesTrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("solving against the whole dictionary is slow")
+	}
+
+	list, err := wordlist.FromFile(path.Join(benchmarkLanguage, "dictionaries", "english.list"), strings.ToUpper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := dictionary.New("english", "en", list, strings.ToUpper)
+
+	for _, size := range []int{SizeStandard, SizeBig, SizeSuperBig} {
+		cubes, err := LoadCubes(path.Join(benchmarkLanguage, fmt.Sprintf("cubes-%dx%d.json", size, size)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for seed := int64(0); seed < 20; seed++ {
+			g := GenerateFromSeed(cubes, seed)
+
+			letters := map[rune]bool{}
+			for _, face := range strings.Join(flatten(g.fold(d)), "") {
+				letters[face] = true
+			}
+
+			want := []string{}
+			for _, word := range list {
+				if utf8.RuneCountInString(word) >= MinimumWordLength && onlyLetters(word, letters) && g.Trace(d, word) != nil {
+					want = append(want, word)
+				}
+			}
+
+			if got := g.Solve(d); !reflect.DeepEqual(got, want) {
+				t.Errorf("%dx%d grid with seed %d solved to %d words, want %d traceable words", size, size, seed, len(got), len(want))
+			}
+		}
+	}
+}
+
+func flatten(faces [][]string) []string {
+	flat := []string{}
+	for _, row := range faces {
+		flat = append(flat, row...)
+	}
+	return flat
+}
+
+func onlyLetters(word string, letters map[rune]bool) bool {
+	for _, letter := range word {
+		if !letters[letter] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkSolve(b *testing.B) {
+	list, err := wordlist.FromFile(path.Join(benchmarkLanguage, "dictionaries", "english.list"), strings.ToUpper)
+	if err != nil {
+		b.Fatal(err)
+	}
+	d := dictionary.New("english", "en", list, strings.ToUpper)
+
+	for _, size := range []int{SizeStandard, SizeBig, SizeSuperBig} {
+		cubes, err := LoadCubes(path.Join(benchmarkLanguage, fmt.Sprintf("cubes-%dx%d.json", size, size)))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		grids := make([]Grid, 16)
+		for i := range grids {
+			grids[i] = GenerateFromSeed(cubes, int64(i))
+		}
+
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				grids[i%len(grids)].Solve(d)
+			}
+		})
+	}
+}
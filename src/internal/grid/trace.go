@@ -0,0 +1,67 @@
+package grid
+
+import (
+	"strings"
+
+	"internal/dictionary"
+)
+
+type Cell struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+type tracer struct {
+	faces [][]string
+	path  []Cell
+}
+
+func (g Grid) Trace(d *dictionary.Dictionary, word string) []Cell {
+	t := tracer{
+		faces: g.fold(d),
+	}
+
+	word = d.Fold(word)
+	for i := range g {
+		for j := range g[i] {
+			if t.recursiveTrace(i, j, 0, word) {
+				return t.path
+			}
+		}
+	}
+	return nil
+}
+
+func (t *tracer) recursiveTrace(i, j int, mask uint64, rest string) bool {
+	face := t.faces[i][j]
+	if face == "" || !strings.HasPrefix(rest, face) {
+		return false
+	}
+
+	mask = strike(mask, len(t.faces), i, j)
+	t.path = append(t.path, Cell{Row: i, Column: j})
+	rest = rest[len(face):]
+
+	if rest == "" {
+		return true
+	}
+
+	for p := i - 1; p <= i+1; p++ {
+		if !(0 <= p && p < len(t.faces)) {
+			continue
+		}
+
+		for q := j - 1; q <= j+1; q++ {
+			if !(0 <= q && q < len(t.faces)) {
+				continue
+			}
+
+			if !struck(mask, len(t.faces), p, q) && t.recursiveTrace(p, q, mask, rest) {
+				return true
+			}
+		}
+	}
+
+	t.path = t.path[:len(t.path)-1]
+	return false
+}
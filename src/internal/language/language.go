@@ -0,0 +1,248 @@
+package language
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"internal/dictionary"
+	"internal/grid"
+	"internal/log"
+	"internal/wordlist"
+)
+
+const Default = "en"
+
+const dictionaryExtension = ".list"
+
+type Language struct {
+	Code string
+	Name string
+
+	letters      map[rune]struct{}
+	caseMapping  unicode.SpecialCase
+	replacements *strings.Replacer
+
+	dictionaries []*dictionary.Dictionary
+	cubes        map[int]grid.CubeSet
+}
+
+type manifest struct {
+	Name         string            `json:"name"`
+	Letters      string            `json:"letters"`
+	Case         string            `json:"case"`
+	Replacements map[string]string `json:"replacements"`
+}
+
+var caseMappings = map[string]unicode.SpecialCase{
+	"":        nil,
+	"turkish": unicode.TurkishCase,
+}
+
+var languages = map[string]*Language{}
+
+// Load reads every language pack under directory, skipping packs that have
+// no dictionary. It must be called before any other function in the package.
+func Load(directory string) error {
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		code := entry.Name()
+		l, err := load(code, path.Join(directory, code))
+		if err != nil {
+			return fmt.Errorf("language pack %q: %s", code, err)
+		}
+
+		if len(l.dictionaries) == 0 {
+			log.Fields{"language": code}.Info("language pack has no dictionaries; skipping")
+			continue
+		}
+
+		languages[code] = l
+	}
+
+	if _, ok := languages[Default]; !ok {
+		return fmt.Errorf("default language pack %q is missing", Default)
+	}
+	return nil
+}
+
+func load(code, directory string) (*Language, error) {
+	data, err := ioutil.ReadFile(path.Join(directory, "language.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	caseMapping, ok := caseMappings[m.Case]
+	if !ok {
+		return nil, fmt.Errorf("unknown case mapping %q", m.Case)
+	}
+
+	replacements := make([]string, 0, 2*len(m.Replacements))
+	for from, to := range m.Replacements {
+		replacements = append(replacements, from, to)
+	}
+
+	l := &Language{
+		Code:         code,
+		Name:         m.Name,
+		letters:      map[rune]struct{}{},
+		caseMapping:  caseMapping,
+		replacements: strings.NewReplacer(replacements...),
+		cubes:        map[int]grid.CubeSet{},
+	}
+
+	for _, letter := range l.Fold(m.Letters) {
+		l.letters[letter] = struct{}{}
+	}
+
+	for size := grid.SizeStandard; size <= grid.SizeSuperBig; size++ {
+		cubes, err := grid.LoadCubes(path.Join(directory, fmt.Sprintf("cubes-%dx%d.json", size, size)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if cubes.Size() != size {
+			return nil, fmt.Errorf("cubes-%dx%d.json holds a %dx%d cube set", size, size, cubes.Size(), cubes.Size())
+		}
+
+		for _, cube := range cubes {
+			for _, face := range cube {
+				if !l.Valid(face) {
+					return nil, fmt.Errorf("cube face %q is not in the alphabet", face)
+				}
+			}
+		}
+
+		l.cubes[size] = cubes
+	}
+
+	if _, ok := l.cubes[grid.SizeStandard]; !ok {
+		return nil, fmt.Errorf("no %dx%d cube set", grid.SizeStandard, grid.SizeStandard)
+	}
+
+	files, err := ioutil.ReadDir(path.Join(directory, "dictionaries"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || path.Ext(file.Name()) != dictionaryExtension {
+			continue
+		}
+
+		name := strings.TrimSuffix(file.Name(), dictionaryExtension)
+
+		list, err := wordlist.FromFile(path.Join(directory, "dictionaries", file.Name()), l.Fold)
+		if err != nil {
+			return nil, err
+		}
+
+		l.dictionaries = append(l.dictionaries, dictionary.New(name, code, list, l.Fold))
+		log.Fields{"language": code, "dictionary": name, "words": len(list)}.Debug("loaded dictionary")
+	}
+
+	sort.Slice(l.dictionaries, func(i, j int) bool {
+		return l.dictionaries[i].Name < l.dictionaries[j].Name
+	})
+
+	return l, nil
+}
+
+func Get(code string) (*Language, bool) {
+	l, ok := languages[code]
+	return l, ok
+}
+
+func Codes() []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func (l *Language) Fold(s string) string {
+	return l.replacements.Replace(strings.ToUpperSpecial(l.caseMapping, s))
+}
+
+func (l *Language) Lower(s string) string {
+	return strings.ToLowerSpecial(l.caseMapping, s)
+}
+
+func (l *Language) Valid(word string) bool {
+	if word == "" {
+		return false
+	}
+
+	for _, letter := range l.Fold(word) {
+		if _, ok := l.letters[letter]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Language) Cubes(size int) (grid.CubeSet, bool) {
+	cubes, ok := l.cubes[size]
+	return cubes, ok
+}
+
+func (l *Language) Sizes() []int {
+	sizes := make([]int, 0, len(l.cubes))
+	for size := range l.cubes {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+func (l *Language) Dictionary(name string) (*dictionary.Dictionary, bool) {
+	if name == "" {
+		for _, d := range l.dictionaries {
+			if d.Name == dictionary.Default {
+				return d, true
+			}
+		}
+		return l.dictionaries[0], true
+	}
+
+	for _, d := range l.dictionaries {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func (l *Language) DictionaryNames() []string {
+	names := make([]string, len(l.dictionaries))
+	for i, d := range l.dictionaries {
+		names[i] = d.Name
+	}
+	return names
+}
+
+func (l *Language) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Code)
+}
@@ -1,32 +1,53 @@
 package nickname
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"path"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"internal/log"
 )
 
+const (
+	minimumLength = 2
+	maximumLength = 24
+)
+
 var adjectives []string
 var animals []string
+var blocklist []string
 
 var r *rand.Rand = rand.New(rand.NewSource(time.Now().Unix()))
 
 type Generator map[string]struct{}
 
-func init() {
+// Load reads the adjectives and animals nicknames are generated from, and the
+// optional nickname blocklist, from directory. It must be called before
+// Generate or Validate.
+func Load(directory string) error {
 	var err error
 
-	if adjectives, err = load(path.Join("config", "adjectives.list")); err != nil {
-		log.Fields{"error": err}.Panic("unable to load nickname list")
+	if adjectives, err = load(path.Join(directory, "adjectives.list")); err != nil {
+		return err
+	}
+
+	if animals, err = load(path.Join(directory, "animals.list")); err != nil {
+		return err
 	}
 
-	if animals, err = load(path.Join("config", "animals.list")); err != nil {
-		log.Fields{"error": err}.Panic("unable to load nickname list")
+	if blocklist, err = load(path.Join(directory, "nickname-blocklist.list")); err != nil {
+		log.Fields{"error": err}.Info("no nickname blocklist loaded")
+	}
+	for i, word := range blocklist {
+		blocklist[i] = strings.ToLower(word)
 	}
+	return nil
 }
 
 func load(path string) ([]string, error) {
@@ -41,16 +62,66 @@ func Generate() string {
 	return adjectives[r.Intn(len(adjectives))] + " " + animals[r.Intn(len(animals))]
 }
 
+// Normalize collapses runs of whitespace in a requested nickname.
+func Normalize(nick string) string {
+	return strings.Join(strings.Fields(nick), " ")
+}
+
+func Validate(nick string) error {
+	if length := utf8.RuneCountInString(nick); length < minimumLength || length > maximumLength {
+		return fmt.Errorf("Nicknames must be between %d and %d characters long", minimumLength, maximumLength)
+	}
+
+	hasLetter := false
+	for _, r := range nick {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r), r == ' ', r == '-', r == '_', r == '\'':
+		default:
+			return errors.New("Nicknames may contain only letters, numbers, spaces, dashes, underscores, and apostrophes")
+		}
+	}
+
+	if !hasLetter {
+		return errors.New("Nicknames must contain at least one letter")
+	}
+
+	lower := strings.ToLower(nick)
+	for _, word := range blocklist {
+		if word != "" && strings.Contains(lower, word) {
+			return errors.New("That nickname is not allowed")
+		}
+	}
+
+	return nil
+}
+
 func (g Generator) Generate() string {
 	for {
 		nick := Generate()
-		if _, ok := g[nick]; !ok {
-			g[nick] = struct{}{}
+		if g.Reserve(nick) {
 			return nick
 		}
 	}
 }
 
+// Reserve claims a nickname, returning false if it is already in use. Names
+// are compared case-insensitively, so two players may not differ only in case.
+func (g Generator) Reserve(nick string) bool {
+	key := strings.ToLower(nick)
+	if _, ok := g[key]; ok {
+		return false
+	}
+	g[key] = struct{}{}
+	return true
+}
+
+// Same reports whether two nicknames would claim the same reservation.
+func Same(a, b string) bool {
+	return strings.ToLower(a) == strings.ToLower(b)
+}
+
 func (g Generator) Free(nick string) {
-	delete(g, nick)
+	delete(g, strings.ToLower(nick))
 }
@@ -0,0 +1,39 @@
+package nickname
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"internal/log"
+)
+
+func TestMain(m *testing.M) {
+	if err := Load(path.Join("..", "..", "..", "config")); err != nil {
+		log.Fields{"error": err}.Fatal("couldn't load nickname lists")
+	}
+	os.Exit(m.Run())
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		nick  string
+		valid bool
+	}{
+		{"Bob", true},
+		{"Brave Otter", true},
+		{"o'Neil-99", true},
+		{"B", false},
+		{"1234", false},
+		{"Bob!", false},
+		{"Moderator", false},
+		{"xXFuckXx", false},
+		{"big SHIT", false},
+	}
+
+	for _, c := range cases {
+		if err := Validate(c.nick); (err == nil) != c.valid {
+			t.Errorf("validating %q returned %v, want valid %t", c.nick, err, c.valid)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const writeWait = 10 * time.Second
+
+// Client is a minimal Goword client: it speaks the current protocol version
+// and hands back typed messages. Receive must not be called concurrently
+// with itself, nor Send with itself.
+type Client struct {
+	conn *websocket.Conn
+}
+
+type DialOptions struct {
+	// ResumeToken recovers the session of a client that lost its
+	// connection.
+	ResumeToken string
+
+	// SessionToken signs in with the account it was issued for by
+	// /accounts/login.
+	SessionToken string
+}
+
+// Dial connects to the engine endpoint at address (e.g. "ws://host:8080").
+func Dial(address string, options DialOptions) (*Client, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/engine"
+
+	query := u.Query()
+	query.Set(VersionParam, strconv.Itoa(Version))
+	if options.ResumeToken != "" {
+		query.Set("resume", options.ResumeToken)
+	}
+	u.RawQuery = query.Encode()
+
+	header := http.Header{}
+	if options.SessionToken != "" {
+		header.Set("Authorization", "Bearer "+options.SessionToken)
+	}
+
+	conn, response, err := websocket.DefaultDialer.Dial(u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+
+	if version := response.Header.Get(VersionHeader); version != strconv.Itoa(Version) {
+		conn.Close()
+		return nil, fmt.Errorf("server speaks protocol version %q, expected %d", version, Version)
+	}
+
+	return &Client{conn: conn}, nil
+}
+
+func (c *Client) Send(r Request) error {
+	data, err := EncodeRequest(r)
+	if err != nil {
+		return err
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Receive blocks until the next message arrives. Messages of a type this
+// package doesn't know are returned as an UnknownMessageError, after which
+// the client remains usable.
+func (c *Client) Receive() (Message, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeMessage(data)
+}
+
+// Close says goodbye to the server, which releases the client's nickname
+// and lobby membership immediately rather than holding them for resumption.
+func (c *Client) Close() error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
@@ -0,0 +1,227 @@
+package protocol
+
+import "time"
+
+const (
+	StateAwaitingPlayers = "awaitingPlayers"
+	StateBetweenGames    = "betweenGames"
+	StateCountdown       = "countdown"
+	StateInGame          = "inGame"
+)
+
+const (
+	WordValid           = "valid"
+	WordTooShort        = "tooShort"
+	WordDuplicate       = "duplicate"
+	WordNotOnBoard      = "notOnBoard"
+	WordNotInDictionary = "notInDictionary"
+)
+
+type State struct {
+	Message     string `json:"message,omitempty"`
+	ResumeToken string `json:"resumeToken,omitempty"`
+	ID          string `json:"id"`
+	AccountID   string `json:"accountId,omitempty"`
+	Nickname    string `json:"nickname"`
+	Rating      int    `json:"rating,omitempty"`
+	Lobby       *Lobby `json:"lobby,omitempty"`
+	Spectator   bool   `json:"spectator,omitempty"`
+}
+
+type Lobby struct {
+	Name             string     `json:"name"`
+	State            string     `json:"state"`
+	SecondsRemaining *float64   `json:"secondsRemaining,omitempty"`
+	Owner            string     `json:"owner,omitempty"`
+	Private          bool       `json:"private"`
+	Rated            bool       `json:"rated"`
+	Players          []Player   `json:"players"`
+	Spectators       []Identity `json:"spectators"`
+	Settings         Settings   `json:"settings"`
+	Language         string     `json:"language"`
+	Size             int        `json:"size"`
+	Dictionary       string     `json:"dictionary"`
+	Grid             [][]string `json:"grid"`
+	MasterSolution   *Result    `json:"masterSolution,omitempty"`
+	GameID           string     `json:"gameId,omitempty"`
+	Challenge        *Challenge `json:"challenge,omitempty"`
+}
+
+// Challenge marks a lobby as a daily challenge attempt; the standing fields
+// are set once it has finished.
+type Challenge struct {
+	Day        string   `json:"day"`
+	Finished   bool     `json:"finished"`
+	Rank       int      `json:"rank,omitempty"`
+	Percentile *float64 `json:"percentile,omitempty"`
+	Players    int      `json:"players,omitempty"`
+}
+
+// Player returns the lobby's player with the given ID, or nil.
+func (l *Lobby) Player(id string) *Player {
+	for i := range l.Players {
+		if l.Players[i].ID == id {
+			return &l.Players[i]
+		}
+	}
+	return nil
+}
+
+type Identity struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId,omitempty"`
+	Nickname  string `json:"nickname"`
+	Rating    int    `json:"rating,omitempty"`
+}
+
+type Player struct {
+	Identity
+	Readied    bool    `json:"readied"`
+	Score      int     `json:"score"`
+	WordsFound int     `json:"wordsFound"`
+	Result     *Result `json:"result,omitempty"`
+}
+
+type Result struct {
+	Score int          `json:"score"`
+	Words []ScoredWord `json:"words"`
+}
+
+type ScoredWord struct {
+	Word   string `json:"word"`
+	Points int    `json:"points"`
+	Found  bool   `json:"found,omitempty"`
+}
+
+const (
+	ErrorUnknownCommand = "unknown_command"
+	ErrorBadPayload     = "bad_payload"
+	ErrorBadArgument    = "bad_argument"
+	ErrorNotInLobby     = "not_in_lobby"
+	ErrorAlreadyInLobby = "already_in_lobby"
+	ErrorWrongState     = "wrong_state"
+	ErrorForbidden      = "forbidden"
+	ErrorNotFound       = "not_found"
+	ErrorLobbyExists    = "lobby_exists"
+	ErrorNameTaken      = "name_taken"
+	ErrorRateLimited    = "rate_limited"
+	ErrorRejected       = "rejected"
+	ErrorSessionExpired = "session_expired"
+	ErrorInternal       = "internal"
+	ErrorShuttingDown   = "shutting_down"
+)
+
+type Error struct {
+	Command string `json:"command"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Cell struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+type Word struct {
+	Word   string `json:"word"`
+	Status string `json:"status"`
+	Path   []Cell `json:"path,omitempty"`
+}
+
+type Chat struct {
+	FromID    string `json:"fromId"`
+	From      string `json:"from"`
+	Text      string `json:"text"`
+	Spectator bool   `json:"spectator,omitempty"`
+}
+
+type LobbySummary struct {
+	Name             string   `json:"name"`
+	State            string   `json:"state"`
+	Players          int      `json:"players"`
+	Spectators       int      `json:"spectators"`
+	SecondsRemaining *float64 `json:"secondsRemaining,omitempty"`
+	Language         string   `json:"language"`
+	Size             int      `json:"size"`
+	Dictionary       string   `json:"dictionary"`
+	Settings         Settings `json:"settings"`
+	Rated            bool     `json:"rated"`
+}
+
+type LobbyList struct {
+	Lobbies []LobbySummary `json:"lobbies"`
+}
+
+// Game is a finished game as recorded in the server's history; Settings
+// durations are in seconds.
+type Game struct {
+	ID         string       `json:"id"`
+	Lobby      string       `json:"lobby"`
+	Language   string       `json:"language"`
+	Dictionary string       `json:"dictionary"`
+	Size       int          `json:"size"`
+	Grid       [][]string   `json:"grid"`
+	Seed       int64        `json:"seed"`
+	Settings   Settings     `json:"settings"`
+	StartedAt  time.Time    `json:"startedAt"`
+	EndedAt    time.Time    `json:"endedAt"`
+	Players    []GamePlayer `json:"players"`
+	Events     []Event      `json:"events,omitempty"`
+}
+
+type GamePlayer struct {
+	ID           string       `json:"id"`
+	AccountID    string       `json:"accountId,omitempty"`
+	Nickname     string       `json:"nickname"`
+	Score        int          `json:"score"`
+	Words        []ScoredWord `json:"words"`
+	Rating       int          `json:"rating,omitempty"`
+	RatingChange int          `json:"ratingChange,omitempty"`
+}
+
+const (
+	EventStart  = "start"
+	EventEnd    = "end"
+	EventJoin   = "join"
+	EventPart   = "part"
+	EventDetach = "detach"
+	EventResume = "resume"
+	EventWord   = "word"
+)
+
+// Event is one entry in a game's timeline. Offset is in milliseconds since the
+// game started.
+type Event struct {
+	Offset   int64  `json:"offset"`
+	Type     string `json:"type"`
+	PlayerID string `json:"playerId,omitempty"`
+	Nickname string `json:"nickname,omitempty"`
+	Word     string `json:"word,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// Replay begins a replay; the game's events follow as ReplayEvents, and
+// ReplayEnd marks the last.
+type Replay struct {
+	GameID string  `json:"gameId"`
+	Speed  float64 `json:"speed"`
+	Game   Game    `json:"game"`
+}
+
+type ReplayEvent struct {
+	GameID string `json:"gameId"`
+	Event  Event  `json:"event"`
+}
+
+type ReplayEnd struct {
+	GameID string `json:"gameId"`
+}
+
+func (*State) Type() string       { return "state" }
+func (*Error) Type() string       { return "error" }
+func (*Word) Type() string        { return "word" }
+func (*Chat) Type() string        { return "chat" }
+func (*LobbyList) Type() string   { return "lobbies" }
+func (*Replay) Type() string      { return "replay" }
+func (*ReplayEvent) Type() string { return "replayEvent" }
+func (*ReplayEnd) Type() string   { return "replayEnd" }
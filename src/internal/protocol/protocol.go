@@ -0,0 +1,151 @@
+// Package protocol describes the messages exchanged over the /engine
+// websocket. Clients request a version with the "version" query parameter;
+// the server answers with the version it speaks in the
+// Goword-Protocol-Version header of the upgrade response.
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	Version       = 1
+	VersionParam  = "version"
+	VersionHeader = "Goword-Protocol-Version"
+)
+
+func Supported(version int) bool {
+	return version == Version
+}
+
+type UnknownCommandError struct {
+	Command string
+}
+
+func (e UnknownCommandError) Error() string {
+	if e.Command == "" {
+		return "missing command"
+	}
+	return fmt.Sprintf("unknown command %q", e.Command)
+}
+
+// PayloadError reports a frame that isn't a JSON object, or whose fields
+// don't have the types its command expects.
+type PayloadError struct {
+	Command string
+	Err     error
+}
+
+func (e PayloadError) Error() string {
+	if e.Command == "" {
+		return "malformed request: " + e.Err.Error()
+	}
+	return fmt.Sprintf("malformed %q request: %s", e.Command, e.Err)
+}
+
+type UnknownMessageError struct {
+	Type string
+}
+
+func (e UnknownMessageError) Error() string {
+	return fmt.Sprintf("unknown message type %q", e.Type)
+}
+
+type Request interface {
+	Command() string
+}
+
+var requests = map[string]func() Request{
+	"join":      func() Request { return &JoinRequest{} },
+	"part":      func() Request { return &PartRequest{} },
+	"ready":     func() Request { return &ReadyRequest{} },
+	"word":      func() Request { return &WordRequest{} },
+	"configure": func() Request { return &ConfigureRequest{} },
+	"list":      func() Request { return &ListRequest{} },
+	"spectate":  func() Request { return &SpectateRequest{} },
+	"chat":      func() Request { return &ChatRequest{} },
+	"nick":      func() Request { return &NickRequest{} },
+	"replay":    func() Request { return &ReplayRequest{} },
+	"challenge": func() Request { return &ChallengeRequest{} },
+}
+
+// EncodeRequest marshals a request along with the command field that
+// identifies it on the wire.
+func EncodeRequest(r Request) ([]byte, error) {
+	return encodeTagged("command", r.Command(), r)
+}
+
+// DecodeRequest returns a pointer to the typed request named by the frame's
+// command field, or an UnknownCommandError or PayloadError.
+func DecodeRequest(data []byte) (Request, error) {
+	var envelope struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, PayloadError{Err: err}
+	}
+
+	constructor, ok := requests[envelope.Command]
+	if !ok {
+		return nil, UnknownCommandError{envelope.Command}
+	}
+
+	request := constructor()
+	if err := json.Unmarshal(data, request); err != nil {
+		return nil, PayloadError{envelope.Command, err}
+	}
+	return request, nil
+}
+
+type Message interface {
+	Type() string
+}
+
+var messages = map[string]func() Message{
+	"state":       func() Message { return &State{} },
+	"error":       func() Message { return &Error{} },
+	"word":        func() Message { return &Word{} },
+	"chat":        func() Message { return &Chat{} },
+	"lobbies":     func() Message { return &LobbyList{} },
+	"replay":      func() Message { return &Replay{} },
+	"replayEvent": func() Message { return &ReplayEvent{} },
+	"replayEnd":   func() Message { return &ReplayEnd{} },
+}
+
+// DecodeMessage returns a pointer to the typed message named by the frame's
+// type field, or an UnknownMessageError.
+func DecodeMessage(data []byte) (Message, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+
+	constructor, ok := messages[envelope.Type]
+	if !ok {
+		return nil, UnknownMessageError{envelope.Type}
+	}
+
+	message := constructor()
+	if err := json.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func encodeTagged(key, value string, payload interface{}) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	fields[key], _ = json.Marshal(value)
+	return json.Marshal(fields)
+}
@@ -0,0 +1,70 @@
+package protocol
+
+// Settings durations are expressed in seconds; zero leaves a setting
+// unchanged.
+type Settings struct {
+	GameDuration         int `json:"gameDuration,omitempty"`
+	CountdownDuration    int `json:"countdownDuration,omitempty"`
+	IntermissionDuration int `json:"intermissionDuration,omitempty"`
+	MinimumPlayers       int `json:"minimumPlayers,omitempty"`
+}
+
+type JoinRequest struct {
+	LobbyName  string `json:"lobbyName"`
+	Language   string `json:"language,omitempty"`
+	Size       int    `json:"size,omitempty"`
+	Dictionary string `json:"dictionary,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+	Rated      bool   `json:"rated,omitempty"`
+	Settings
+}
+
+type PartRequest struct{}
+
+type ReadyRequest struct{}
+
+type WordRequest struct {
+	Word string `json:"word"`
+}
+
+type ConfigureRequest struct {
+	Settings
+}
+
+type ListRequest struct{}
+
+type SpectateRequest struct {
+	LobbyName  string `json:"lobbyName"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+type ChatRequest struct {
+	Text string `json:"text"`
+}
+
+type NickRequest struct {
+	Nickname string `json:"nickname"`
+}
+
+// ReplayRequest asks for a finished game to be replayed Speed times faster
+// than it was played; zero replays it in real time.
+type ReplayRequest struct {
+	GameID string  `json:"gameId"`
+	Speed  float64 `json:"speed,omitempty"`
+}
+
+// ChallengeRequest starts the daily challenge; only signed-in players may
+// play it, once a day.
+type ChallengeRequest struct{}
+
+func (*JoinRequest) Command() string      { return "join" }
+func (*PartRequest) Command() string      { return "part" }
+func (*ReadyRequest) Command() string     { return "ready" }
+func (*WordRequest) Command() string      { return "word" }
+func (*ConfigureRequest) Command() string { return "configure" }
+func (*ListRequest) Command() string      { return "list" }
+func (*SpectateRequest) Command() string  { return "spectate" }
+func (*ChatRequest) Command() string      { return "chat" }
+func (*NickRequest) Command() string      { return "nick" }
+func (*ReplayRequest) Command() string    { return "replay" }
+func (*ChallengeRequest) Command() string { return "challenge" }
@@ -0,0 +1,39 @@
+package ratelimit
+
+import "time"
+
+// Bucket is a token bucket holding at most Burst tokens and refilling at Rate
+// tokens per second. It is not safe for concurrent use; each bucket should be
+// owned by a single goroutine.
+type Bucket struct {
+	Rate  float64
+	Burst float64
+
+	tokens  float64
+	updated time.Time
+}
+
+func New(rate, burst float64) *Bucket {
+	return &Bucket{
+		Rate:    rate,
+		Burst:   burst,
+		tokens:  burst,
+		updated: time.Now(),
+	}
+}
+
+func (b *Bucket) Allow() bool {
+	now := time.Now()
+	b.tokens += now.Sub(b.updated).Seconds() * b.Rate
+	if b.tokens > b.Burst {
+		b.tokens = b.Burst
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
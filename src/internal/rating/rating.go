@@ -0,0 +1,179 @@
+// Package rating maintains multiplayer Elo ratings for accounts.
+package rating
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"internal/store"
+)
+
+const (
+	Initial = 1500
+
+	// kFactor bounds how far a single head-to-head result moves a rating.
+	kFactor = 32
+)
+
+type Rating struct {
+	Rating int `json:"rating"`
+	Games  int `json:"games"`
+}
+
+type Entry struct {
+	Rank      int    `json:"rank"`
+	AccountID string `json:"accountId"`
+	Rating
+}
+
+// Table holds the current rating of every account that has played a rated
+// game. It is safe for concurrent use.
+type Table struct {
+	sync.RWMutex
+	ratings map[string]Rating
+}
+
+func New() *Table {
+	return &Table{
+		ratings: map[string]Rating{},
+	}
+}
+
+// Get returns an account's rating, or the initial rating if it has never
+// played a rated game.
+func (t *Table) Get(accountID string) Rating {
+	t.RLock()
+	defer t.RUnlock()
+	return t.get(accountID)
+}
+
+func (t *Table) get(accountID string) Rating {
+	if rating, ok := t.ratings[accountID]; ok {
+		return rating
+	}
+	return Rating{Rating: Initial}
+}
+
+// Rate notes each signed-in player's new rating and change on a finished
+// game, without recording them; Record the game once it is kept, so that the
+// table never runs ahead of the history it is rebuilt from. Games with fewer
+// than two signed-in players are unrated.
+//
+// Each pair of players is scored as a head-to-head match decided by their
+// totals, and the per-pair changes are averaged so that a rating moves about
+// as far in a crowded lobby as in a one-on-one game.
+func (t *Table) Rate(game *store.Game) {
+	rated := ratedPlayers(game)
+	if len(rated) < 2 {
+		return
+	}
+
+	t.RLock()
+	before := make([]Rating, len(rated))
+	for k, i := range rated {
+		before[k] = t.get(game.Players[i].AccountID)
+	}
+	t.RUnlock()
+
+	for k, i := range rated {
+		change := 0.0
+		for l, j := range rated {
+			if k == l {
+				continue
+			}
+
+			actual := 0.5
+			if game.Players[i].Score > game.Players[j].Score {
+				actual = 1
+			} else if game.Players[i].Score < game.Players[j].Score {
+				actual = 0
+			}
+			expected := 1 / (1 + math.Pow(10, float64(before[l].Rating-before[k].Rating)/400))
+			change += kFactor * (actual - expected)
+		}
+		change /= float64(len(rated) - 1)
+
+		after := before[k].Rating + int(math.Round(change))
+		if after < 1 {
+			after = 1
+		}
+		game.Players[i].Rating = after
+		game.Players[i].RatingChange = after - before[k].Rating
+	}
+}
+
+// Record updates the ratings of the signed-in players in a finished game. A
+// game that already carries ratings, from Rate or read back from history, is
+// applied as noted; otherwise it is rated first.
+func (t *Table) Record(game *store.Game) {
+	rated := ratedPlayers(game)
+	if len(rated) < 2 {
+		return
+	}
+
+	recorded := false
+	for _, i := range rated {
+		if game.Players[i].Rating != 0 {
+			recorded = true
+		}
+	}
+	if !recorded {
+		t.Rate(game)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, i := range rated {
+		player := game.Players[i]
+		if player.Rating == 0 {
+			continue
+		}
+		t.ratings[player.AccountID] = Rating{
+			Rating: player.Rating,
+			Games:  t.get(player.AccountID).Games + 1,
+		}
+	}
+}
+
+// ratedPlayers returns the indices of a game's signed-in players.
+func ratedPlayers(game *store.Game) []int {
+	rated := []int{}
+	for i, player := range game.Players {
+		if player.AccountID != "" {
+			rated = append(rated, i)
+		}
+	}
+	return rated
+}
+
+// Top ranks accounts by rating, returning at most limit entries. Accounts
+// with equal ratings share a rank.
+func (t *Table) Top(limit int) []Entry {
+	t.RLock()
+	entries := make([]Entry, 0, len(t.ratings))
+	for accountID, rating := range t.ratings {
+		entries = append(entries, Entry{AccountID: accountID, Rating: rating})
+	}
+	t.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Rating.Rating != entries[j].Rating.Rating {
+			return entries[i].Rating.Rating > entries[j].Rating.Rating
+		}
+		return entries[i].AccountID < entries[j].AccountID
+	})
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 && entries[i-1].Rating.Rating == entries[i].Rating.Rating {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,129 @@
+package rating
+
+import (
+	"testing"
+
+	"internal/store"
+)
+
+func TestRecord(t *testing.T) {
+	type player struct {
+		accountID string
+		rating    int
+		score     int
+
+		// want is the player's rating after the game, and 0 if the game
+		// shouldn't rate them.
+		want int
+	}
+
+	cases := []struct {
+		name    string
+		players []player
+	}{
+		{"two players", []player{
+			{"a", 1500, 10, 1516},
+			{"b", 1500, 5, 1484},
+		}},
+		{"favourite wins", []player{
+			{"a", 1600, 10, 1608},
+			{"b", 1400, 5, 1392},
+		}},
+		{"underdog wins", []player{
+			{"a", 1600, 5, 1576},
+			{"b", 1400, 10, 1424},
+		}},
+		{"tie", []player{
+			{"a", 1500, 7, 1500},
+			{"b", 1500, 7, 1500},
+		}},
+		{"uneven tie", []player{
+			{"a", 1600, 7, 1592},
+			{"b", 1400, 7, 1408},
+		}},
+		{"three players", []player{
+			{"a", 1500, 10, 1516},
+			{"b", 1500, 5, 1500},
+			{"c", 1500, 0, 1484},
+		}},
+		{"four players with a tie", []player{
+			{"a", 1500, 10, 1516},
+			{"b", 1500, 5, 1500},
+			{"c", 1500, 5, 1500},
+			{"d", 1500, 0, 1484},
+		}},
+		{"anonymous players aren't rated", []player{
+			{"a", 1500, 10, 1516},
+			{"", 0, 20, 0},
+			{"b", 1500, 5, 1484},
+		}},
+		{"one signed-in player is unrated", []player{
+			{"a", 1500, 10, 0},
+			{"", 0, 5, 0},
+		}},
+	}
+
+	for _, c := range cases {
+		table := New()
+		game := store.Game{}
+		for _, p := range c.players {
+			if p.accountID != "" && p.rating != Initial {
+				table.ratings[p.accountID] = Rating{Rating: p.rating, Games: 3}
+			}
+			game.Players = append(game.Players, store.Player{AccountID: p.accountID, Score: p.score})
+		}
+
+		table.Record(&game)
+
+		for i, p := range c.players {
+			got := game.Players[i]
+			if got.Rating != p.want {
+				t.Errorf("%s: player %d is rated %d, want %d", c.name, i, got.Rating, p.want)
+			}
+			if p.want != 0 && got.RatingChange != p.want-p.rating {
+				t.Errorf("%s: player %d changed by %d, want %d", c.name, i, got.RatingChange, p.want-p.rating)
+			}
+			if p.accountID == "" {
+				continue
+			}
+
+			want := Rating{Rating: p.rating, Games: 3}
+			if p.rating == Initial {
+				want.Games = 0
+			}
+			if p.want != 0 {
+				want.Rating = p.want
+				want.Games++
+			}
+			if recorded := table.Get(p.accountID); recorded != want {
+				t.Errorf("%s: table holds %+v for player %d, want %+v", c.name, recorded, i, want)
+			}
+		}
+	}
+}
+
+func TestRateLeavesTableAlone(t *testing.T) {
+	table := New()
+	game := store.Game{Players: []store.Player{
+		{AccountID: "a", Score: 10},
+		{AccountID: "b", Score: 5},
+	}}
+
+	table.Rate(&game)
+	if game.Players[0].Rating != 1516 || game.Players[1].Rating != 1484 {
+		t.Fatalf("rated the game %+v, want 1516 and 1484", game.Players)
+	}
+	if a := table.Get("a"); a.Rating != Initial || a.Games != 0 {
+		t.Errorf("rating the game changed the table to %+v", a)
+	}
+
+	// Recording the rated game applies the noted ratings rather than rating
+	// it again.
+	table.Record(&game)
+	if a := table.Get("a"); a.Rating != 1516 || a.Games != 1 {
+		t.Errorf("table holds %+v after recording, want 1516 after one game", a)
+	}
+	if game.Players[0].Rating != 1516 {
+		t.Errorf("recording the game rerated it to %d", game.Players[0].Rating)
+	}
+}
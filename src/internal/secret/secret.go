@@ -0,0 +1,41 @@
+// Package secret loads the random keys the server signs and derives things
+// with, which must stay the same across restarts.
+package secret
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// Load reads a key of at least length bytes from path, generating one and
+// saving it there if the file doesn't exist. A file holding a shorter key is
+// an error rather than being replaced, since replacing a key invalidates
+// everything made with it. With an empty path the key lasts only as long as
+// the process.
+func Load(path string, length int) ([]byte, error) {
+	if path != "" {
+		key, err := ioutil.ReadFile(path)
+		if err == nil {
+			if len(key) < length {
+				return nil, fmt.Errorf("key file %s holds %d bytes, want at least %d; remove it to generate a new key", path, len(key), length)
+			}
+			return key, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	key := make([]byte, length)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		if err := ioutil.WriteFile(path, key, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package secret
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempDirectory(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return directory, func() { os.RemoveAll(directory) }
+}
+
+func TestLoadGeneratesAndKeeps(t *testing.T) {
+	directory, cleanup := tempDirectory(t)
+	defer cleanup()
+	path := filepath.Join(directory, "test.key")
+
+	key, err := Load(path, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("generated a %d byte key, want 32", len(key))
+	}
+
+	again, err := Load(path, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("loading the key again returned a different key")
+	}
+}
+
+func TestLoadRejectsShortKey(t *testing.T) {
+	directory, cleanup := tempDirectory(t)
+	defer cleanup()
+	path := filepath.Join(directory, "test.key")
+
+	short := []byte("too short")
+	if err := ioutil.WriteFile(path, short, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, 32); err == nil {
+		t.Error("loaded a key shorter than asked for")
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contents, short) {
+		t.Errorf("short key file was overwritten with %q", contents)
+	}
+}
+
+func TestLoadWithoutPath(t *testing.T) {
+	first, err := Load("", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load("", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 32 || bytes.Equal(first, second) {
+		t.Error("keys without a path should be fresh and 32 bytes long")
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"internal/account"
+	"internal/log"
+	"internal/protocol"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	sessionCookie       = "goword_session"
+	sessionLifetime     = 30 * 24 * time.Hour
+	maxAccountsBody     = 4096
+	errorBadCredentials = "bad_credentials"
+)
+
+type accounts struct {
+	store    account.Store
+	sessions *account.Sessions
+	proxies  trustedProxies
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type sessionResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func registerHandler(a *accounts, limiter *addressLimiter) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if !limiter.allow(r) {
+			rejectCredentials(w)
+			return
+		}
+
+		request, ok := readCredentials(w, r)
+		if !ok {
+			return
+		}
+
+		created, err := account.Register(a.store, request.Username, request.Password)
+		if err == account.ErrUsernameTaken {
+			writeJSON(w, http.StatusConflict, errorResponse{protocol.ErrorNameTaken, err.Error()})
+			return
+		} else if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{protocol.ErrorBadArgument, err.Error()})
+			return
+		}
+
+		log.Fields{"account": created.ID, "username": created.Username}.Info("registered account")
+		a.startSession(w, r, http.StatusCreated, created)
+	}
+}
+
+func loginHandler(a *accounts, limiter *addressLimiter) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if !limiter.allow(r) {
+			rejectCredentials(w)
+			return
+		}
+
+		request, ok := readCredentials(w, r)
+		if !ok {
+			return
+		}
+
+		found, err := account.Login(a.store, request.Username, request.Password)
+		if err == account.ErrBadCredentials {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{errorBadCredentials, err.Error()})
+			return
+		} else if err != nil {
+			log.Fields{"error": err}.Error("couldn't look up account")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{protocol.ErrorInternal, "Couldn't sign in; please try again"})
+			return
+		}
+
+		a.startSession(w, r, http.StatusOK, found)
+	}
+}
+
+// logoutHandler revokes every session of the account the request is signed
+// in with, if any, and clears the session cookie.
+func logoutHandler(a *accounts) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if signedIn := a.authenticate(r); signedIn != nil {
+			if err := account.Revoke(a.store, signedIn.ID); err != nil {
+				log.Fields{"account": signedIn.ID, "error": err}.Error("couldn't revoke sessions")
+				writeJSON(w, http.StatusInternalServerError, errorResponse{protocol.ErrorInternal, "Couldn't sign out; please try again"})
+				return
+			}
+			log.Fields{"account": signedIn.ID}.Info("revoked sessions")
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func rejectCredentials(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(1/credentialRate)))
+	writeJSON(w, http.StatusTooManyRequests, errorResponse{protocol.ErrorRateLimited, "Too many attempts from your address; please wait a little and try again"})
+}
+
+func readCredentials(w http.ResponseWriter, r *http.Request) (credentials, bool) {
+	var request credentials
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAccountsBody)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{protocol.ErrorBadPayload, "Expected a JSON object with a username and password"})
+		return request, false
+	}
+	return request, true
+}
+
+func (a *accounts) startSession(w http.ResponseWriter, r *http.Request, status int, signedIn account.Account) {
+	token := a.sessions.Issue(signedIn)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(a.sessions.Lifetime()),
+		HttpOnly: true,
+		Secure:   a.proxies.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJSON(w, status, sessionResponse{
+		ID:       signedIn.ID,
+		Username: signedIn.Username,
+		Token:    token,
+	})
+}
+
+// authenticate returns the account a request's session cookie or bearer token
+// belongs to, or nil if it has neither or they aren't valid.
+func (a *accounts) authenticate(r *http.Request) *account.Account {
+	token := ""
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		token = cookie.Value
+	}
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	if token == "" {
+		return nil
+	}
+
+	id, generation, ok := a.sessions.Verify(token)
+	if !ok {
+		return nil
+	}
+
+	found, err := a.store.ByID(id)
+	if err != nil || found.SessionGeneration != generation {
+		return nil
+	}
+	return &found
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Fields{"error": err}.Panic("couldn't marshal JSON response")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
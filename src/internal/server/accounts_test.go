@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"internal/account"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func newTestAccounts(t *testing.T) *accounts {
+	sessions, err := account.LoadSessions("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &accounts{
+		store:    account.NewMemory(),
+		sessions: sessions,
+	}
+}
+
+// signIn registers or signs in through handler, returning the session token.
+func signIn(t *testing.T, a *accounts, handler func(http.ResponseWriter, *http.Request, httprouter.Params)) string {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/accounts", strings.NewReader(`{"username": "Alice", "password": "correct horse"}`)), nil)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("signing in answered %d: %s", w.Code, w.Body)
+	}
+
+	var response sessionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response.Token
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest("POST", "/accounts/logout", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestLogoutRevokesSessions(t *testing.T) {
+	a := newTestAccounts(t)
+	limiter := newAddressLimiter(credentialRate, credentialBurst, nil)
+
+	first := signIn(t, a, registerHandler(a, limiter))
+	second := signIn(t, a, loginHandler(a, limiter))
+	if a.authenticate(bearer(first)) == nil || a.authenticate(bearer(second)) == nil {
+		t.Fatal("fresh session tokens aren't accepted")
+	}
+
+	w := httptest.NewRecorder()
+	logoutHandler(a)(w, bearer(first), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("signing out answered %d: %s", w.Code, w.Body)
+	}
+
+	for _, token := range []string{first, second} {
+		if a.authenticate(bearer(token)) != nil {
+			t.Errorf("token %q is still accepted after signing out", token)
+		}
+	}
+
+	if a.authenticate(bearer(signIn(t, a, loginHandler(a, limiter)))) == nil {
+		t.Error("signing in again after signing out doesn't give a working token")
+	}
+}
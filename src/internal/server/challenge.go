@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	"internal/account"
+	"internal/challenge"
+	"internal/log"
+	"internal/protocol"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type challengeEntry struct {
+	Username string `json:"username"`
+	challenge.Entry
+}
+
+type challengeLeaderboardResponse struct {
+	Day     string           `json:"day"`
+	Players int              `json:"players"`
+	Entries []challengeEntry `json:"entries"`
+}
+
+// challengeLeaderboardHandler ranks the finished attempts at a day's
+// challenge, today's unless the day parameter names another.
+func challengeLeaderboardHandler(accounts account.Store, daily *challenge.Daily) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		day := r.URL.Query().Get("day")
+		if day == "" {
+			day = challenge.Today()
+		} else if !challenge.ValidDay(day) {
+			writeJSON(w, http.StatusBadRequest, errorResponse{protocol.ErrorBadArgument, "day must be formatted as YYYY-MM-DD"})
+			return
+		}
+
+		results, err := daily.Results(day)
+		if err != nil {
+			log.Fields{"day": day, "error": err}.Error("couldn't read daily challenge results")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{protocol.ErrorInternal, "Couldn't load the leaderboard; please try again"})
+			return
+		}
+
+		entries := challenge.Leaderboard(results, leaderboardSize)
+		response := challengeLeaderboardResponse{
+			Day:     day,
+			Players: len(results),
+			Entries: make([]challengeEntry, len(entries)),
+		}
+		for i, entry := range entries {
+			response.Entries[i].Entry = entry
+			if found, err := accounts.ByID(entry.AccountID); err == nil {
+				response.Entries[i].Username = found.Username
+			}
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
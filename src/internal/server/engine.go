@@ -1,12 +1,16 @@
 package server
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"internal/account"
 	"internal/engine"
 	"internal/log"
+	"internal/protocol"
+	"internal/ratelimit"
 
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
@@ -18,35 +22,71 @@ const (
 	writeWait       = 10 * time.Second
 	pingPeriod      = 25 * time.Second
 	pongWait        = 30 * time.Second
-	maxMessageSize  = 1024
+
+	// maxMessageSize fits the longest chat message: 280 characters, each of
+	// which may take up to twelve bytes when escaped as a surrogate pair.
+	maxMessageSize = 4096
+
+	// Clients may send the occasional invalid or rate-limited frame, but one
+	// that keeps doing so is disconnected.
+	invalidFrameRate  = 0.2
+	invalidFrameBurst = 10
 )
 
 type client struct {
 	*engine.Client
 	*websocket.Conn
-}
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  readBufferSize,
-	WriteBufferSize: writeBufferSize,
-	CheckOrigin:		 func(r *http.Request) bool { return true },
+	done       chan struct{}
+	writerDone chan struct{}
+	goingAway  chan struct{}
+
+	invalidFrames *ratelimit.Bucket
+	limiter       *requestLimiter
 }
 
-func engineHandler(engine *engine.Engine) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+func engineHandler(engine *engine.Engine, connections *connectionTracker, accounts *accounts, proxies trustedProxies, origins allowedOrigins) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  readBufferSize,
+		WriteBufferSize: writeBufferSize,
+		CheckOrigin:     origins.check,
+	}
+
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-		ws, err := upgrader.Upgrade(w, r, nil)
+		if version := r.URL.Query().Get(protocol.VersionParam); version != "" {
+			if v, err := strconv.Atoi(version); err != nil || !protocol.Supported(v) {
+				http.Error(w, fmt.Sprintf("Unsupported protocol version %q; this server speaks version %d", version, protocol.Version), http.StatusBadRequest)
+				return
+			}
+		}
+
+		header := http.Header{}
+		header.Set(protocol.VersionHeader, strconv.Itoa(protocol.Version))
+		ws, err := upgrader.Upgrade(w, r, header)
 		if err != nil {
 			log.Fields{"error": err}.Info("failed to establish websocket connection")
 			return
 		}
 
-		client := newClient(engine, ws)
+		address := proxies.remoteAddress(r)
+		if !connections.acquire(address) {
+			log.Fields{"address": address}.Info("refusing websocket connection over the per-address limit")
+			_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Too many connections from your address"), time.Now().Add(writeWait))
+			ws.Close()
+			return
+		}
+		defer connections.release(address)
+
+		client := newClient(engine, ws, r.URL.Query().Get("resume"), accounts.authenticate(r))
+		connections.track(&client)
+		defer connections.untrack(&client)
+
 		go client.Writer()
 		client.Reader()
 	}
 }
 
-func newClient(engine *engine.Engine, ws *websocket.Conn) client {
+func newClient(e *engine.Engine, ws *websocket.Conn, resumeToken string, a *account.Account) client {
 	ws.SetReadLimit(maxMessageSize)
 	ws.SetReadDeadline(time.Now().Add(pongWait))
 	ws.SetPongHandler(func(string) error {
@@ -54,10 +94,53 @@ func newClient(engine *engine.Engine, ws *websocket.Conn) client {
 		return nil
 	})
 
-	return client{
-		Client: engine.NewClient(),
-		Conn:   ws,
+	c := client{
+		Conn:          ws,
+		done:          make(chan struct{}),
+		writerDone:    make(chan struct{}),
+		goingAway:     make(chan struct{}),
+		invalidFrames: ratelimit.New(invalidFrameRate, invalidFrameBurst),
+		limiter:       newRequestLimiter(),
 	}
+
+	if resumeToken != "" {
+		c.Client = e.Resume(resumeToken)
+	}
+
+	if c.Client == nil {
+		c.Client = e.NewClient(a)
+		if resumeToken != "" {
+			c.Send(engine.ResumeFailedMessage())
+		}
+	}
+
+	return c
+}
+
+func (c *client) stopWriter() {
+	close(c.done)
+	<-c.writerDone
+}
+
+// disconnect closes the connection with the given close code, giving up the
+// client's session.
+func (c *client) disconnect(code int, reason string) {
+	_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	c.stopWriter()
+	c.Quit()
+}
+
+// reject reports a refused frame to the client, and returns false if the
+// client has had too many refused and was disconnected.
+func (c *client) reject(command, code, message string) bool {
+	if !c.invalidFrames.Allow() {
+		log.Fields{"command": command, "code": code}.Info("disconnecting client for sending too many refused frames")
+		c.disconnect(websocket.ClosePolicyViolation, "Too many refused requests")
+		return false
+	}
+
+	c.Send(engine.ErrorMessage(command, code, message))
+	return true
 }
 
 func (c *client) Reader() {
@@ -67,33 +150,107 @@ func (c *client) Reader() {
 	for {
 		_, data, err := c.ReadMessage()
 		if err != nil {
-			c.Quit()
+			c.stopWriter()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				c.Quit()
+			} else {
+				c.Detach()
+			}
 			return
 		}
 
-		message := map[string]string{}
-		if err = json.Unmarshal(data, &message); err != nil {
+		if !c.limiter.allowFrame() {
+			if !c.reject("", protocol.ErrorRateLimited, "You are sending requests too quickly") {
+				return
+			}
+			continue
+		}
+
+		request, err := protocol.DecodeRequest(data)
+		switch err := err.(type) {
+		case nil:
+		case protocol.UnknownCommandError:
+			log.Fields{"command": err.Command}.Debug("client sent an unknown command")
+			if !c.reject(err.Command, protocol.ErrorUnknownCommand, "Unknown command") {
+				return
+			}
+			continue
+		case protocol.PayloadError:
 			log.Fields{"error": err}.Debug("error unmarshalling incoming JSON payload")
-			c.Quit()
+			if !c.reject(err.Command, protocol.ErrorBadPayload, "Malformed request") {
+				return
+			}
+			continue
+		default:
+			log.Fields{"error": err}.Error("unexpected error decoding request")
+			c.disconnect(websocket.CloseInternalServerErr, "")
 			return
 		}
 
-		switch message["command"] {
-		case "join":
-			c.Join(message["lobbyName"])
-		case "part":
+		if !c.limiter.allowCommand(request.Command()) {
+			if !c.reject(request.Command(), protocol.ErrorRateLimited, "You are sending requests too quickly") {
+				return
+			}
+			continue
+		}
+
+		switch r := request.(type) {
+		case *protocol.JoinRequest:
+			c.Join(r.LobbyName, engine.JoinOptions{
+				Language:   r.Language,
+				Size:       r.Size,
+				Dictionary: r.Dictionary,
+				Settings:   settingsRequest(r.Settings),
+				Passphrase: r.Passphrase,
+				Rated:      r.Rated,
+			})
+		case *protocol.PartRequest:
 			c.Part()
-		case "ready":
+		case *protocol.ReadyRequest:
 			c.Ready()
-		case "word":
-			c.Word(message["word"])
+		case *protocol.WordRequest:
+			c.Word(r.Word)
+		case *protocol.ConfigureRequest:
+			c.Configure(settingsRequest(r.Settings))
+		case *protocol.ListRequest:
+			c.List()
+		case *protocol.SpectateRequest:
+			c.Spectate(r.LobbyName, r.Passphrase)
+		case *protocol.ChatRequest:
+			c.Chat(r.Text)
+		case *protocol.NickRequest:
+			c.Nick(r.Nickname)
+		case *protocol.ReplayRequest:
+			c.Replay(r.GameID, r.Speed)
+		case *protocol.ChallengeRequest:
+			c.Challenge()
+		}
+	}
+}
+
+func settingsRequest(settings protocol.Settings) engine.SettingsRequest {
+	return engine.SettingsRequest{
+		GameDuration:         time.Duration(settings.GameDuration) * time.Second,
+		CountdownDuration:    time.Duration(settings.CountdownDuration) * time.Second,
+		IntermissionDuration: time.Duration(settings.IntermissionDuration) * time.Second,
+		MinimumPlayers:       settings.MinimumPlayers,
+	}
+}
+
+func (c *client) writeFrames(frames [][]byte) bool {
+	for _, data := range frames {
+		c.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
+			return false
 		}
 	}
+	return true
 }
 
 func (c *client) Writer() {
 	ticker := time.NewTicker(pingPeriod)
 
+	defer close(c.writerDone)
 	defer ticker.Stop()
 	defer c.Close()
 	defer log.Debug("HTTP engine writer terminating")
@@ -101,24 +258,39 @@ func (c *client) Writer() {
 
 	for {
 		select {
-		case response, ok := <-c.OutgoingPipe:
-			if !ok {
-				_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		case <-c.Pending():
+			frames, status := c.Drain()
+			if !c.writeFrames(frames) {
 				return
 			}
 
-			data, err := json.Marshal(response)
-			if err != nil {
-				log.Fields{"error": err}.Panic("couldn't marshal response")
+			switch status {
+			case engine.OutboxClosed:
+				c.SetWriteDeadline(time.Now().Add(writeWait))
+				_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			case engine.OutboxLagging:
+				log.Info("disconnecting client that fell too far behind")
+				c.SetWriteDeadline(time.Now().Add(writeWait))
+				_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Connection fell too far behind"))
+				return
 			}
-
-			if err = c.WriteMessage(websocket.TextMessage, data); err != nil {
+		case <-c.goingAway:
+			frames, _ := c.Drain()
+			if !c.writeFrames(frames) {
 				return
 			}
+
+			c.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "Server is shutting down"))
+			return
 		case <-ticker.C:
+			c.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.WriteMessage(websocket.PingMessage, []byte("ping")); err != nil {
 				return
 			}
+		case <-c.done:
+			return
 		}
 	}
 }
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+
+	"internal/log"
+	"internal/protocol"
+	"internal/store"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const recentGamesSize = 20
+
+type gamesResponse struct {
+	Games []store.Game `json:"games"`
+}
+
+// gamesHandler lists the latest finished games, newest first, optionally only
+// those played in a lobby or by an account. Timelines are left out; fetch a
+// game's replay for those.
+func gamesHandler(history store.Store) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		query := r.URL.Query()
+		games, err := history.Recent(store.Query{
+			Lobby:     query.Get("lobby"),
+			AccountID: query.Get("account"),
+			Limit:     recentGamesSize,
+		})
+		if err != nil {
+			log.Fields{"error": err}.Error("couldn't list recent games")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{protocol.ErrorInternal, "Couldn't list games; please try again"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, gamesResponse{games})
+	}
+}
+
+// replayHandler serves a finished game together with its timeline.
+func replayHandler(history store.Store) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		game, err := history.Game(ps.ByName("id"))
+		if err == store.ErrNotFound {
+			writeJSON(w, http.StatusNotFound, errorResponse{protocol.ErrorNotFound, "There is no game with that ID"})
+			return
+		} else if err != nil {
+			log.Fields{"game": ps.ByName("id"), "error": err}.Error("couldn't read game to replay")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{protocol.ErrorInternal, "Couldn't load that game; please try again"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, game)
+	}
+}
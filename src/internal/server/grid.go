@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"internal/grid"
+	"internal/language"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -15,20 +17,41 @@ func gridHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	var g grid.Grid
 	var seed int64
 
+	query := r.URL.Query()
+
+	lang, ok := language.Get(query.Get("language"))
+	if !ok {
+		lang, _ = language.Get(language.Default)
+	}
+
+	cubes, ok := lang.Cubes(grid.SizeStandard)
+	if size, err := strconv.Atoi(query.Get("size")); err == nil {
+		if sized, ok := lang.Cubes(size); ok {
+			cubes = sized
+		}
+	}
+
+	d, ok := lang.Dictionary(query.Get("dictionary"))
+	if !ok {
+		d, _ = lang.Dictionary("")
+	}
+
 	if seedParam := ps.ByName("seed"); seedParam != "" {
 		var err error
 		seed, err = strconv.ParseInt(seedParam, 10, 64)
 		if err != nil {
-			g = grid.Generate(&seed)
+			g = grid.Generate(cubes, &seed)
 		} else {
-			g = grid.GenerateFromSeed(seed)
+			g = grid.GenerateFromSeed(cubes, seed)
 		}
 	} else {
-		g = grid.Generate(&seed)
+		g = grid.Generate(cubes, &seed)
 	}
 
-	solution := g.Solve()
+	start := time.Now()
+	solution := g.Solve(d)
+	elapsed := time.Since(start)
 
 	w.Header().Set("Content-Type", "text/plain")
-	fmt.Fprintf(w, "Grid #%d:\n%v\nFound %d words:\n%s", seed, g, len(solution), strings.Join(solution, "\n"))
+	fmt.Fprintf(w, "Grid #%d (%dx%d, %s):\n%v\nFound %d words in %v using %s:\n%s", seed, g.Size(), g.Size(), lang.Name, g, len(solution), elapsed, d.Name, strings.Join(solution, "\n"))
 }
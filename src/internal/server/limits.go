@@ -0,0 +1,297 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"internal/ratelimit"
+)
+
+const (
+	maxConnectionsPerAddress = 8
+
+	requestRate  = 10
+	requestBurst = 20
+
+	// Registering and signing in are limited per address, to slow down
+	// password guessing and mass registration.
+	credentialRate  = 0.1
+	credentialBurst = 10
+
+	// addressLimiterPruneSize is how many addresses an addressLimiter tracks
+	// before it forgets those that have been idle long enough to refill.
+	addressLimiterPruneSize = 1024
+)
+
+type limit struct {
+	rate  float64
+	burst float64
+}
+
+// commandLimits caps individual commands more tightly than requestRate;
+// commands not listed are bounded only by the overall rate. Chat has its own
+// limit in the engine.
+var commandLimits = map[string]limit{
+	"join":      {0.5, 3},
+	"spectate":  {0.5, 3},
+	"part":      {0.5, 3},
+	"configure": {1, 5},
+	"nick":      {0.2, 3},
+	"list":      {1, 5},
+	"word":      {5, 10},
+	"replay":    {0.2, 3},
+	"challenge": {0.2, 3},
+}
+
+type requestLimiter struct {
+	requests *ratelimit.Bucket
+	commands map[string]*ratelimit.Bucket
+}
+
+func newRequestLimiter() *requestLimiter {
+	return &requestLimiter{
+		requests: ratelimit.New(requestRate, requestBurst),
+		commands: map[string]*ratelimit.Bucket{},
+	}
+}
+
+func (l *requestLimiter) allowFrame() bool {
+	return l.requests.Allow()
+}
+
+func (l *requestLimiter) allowCommand(command string) bool {
+	bucket, ok := l.commands[command]
+	if !ok {
+		limit, ok := commandLimits[command]
+		if !ok {
+			return true
+		}
+		bucket = ratelimit.New(limit.rate, limit.burst)
+		l.commands[command] = bucket
+	}
+	return bucket.Allow()
+}
+
+// addressLimiter rate limits HTTP requests by the address they come from. It
+// is safe for concurrent use.
+type addressLimiter struct {
+	sync.Mutex
+	rate    float64
+	burst   float64
+	proxies trustedProxies
+	buckets map[string]*addressBucket
+}
+
+type addressBucket struct {
+	*ratelimit.Bucket
+	seen time.Time
+}
+
+func newAddressLimiter(rate, burst float64, proxies trustedProxies) *addressLimiter {
+	return &addressLimiter{
+		rate:    rate,
+		burst:   burst,
+		proxies: proxies,
+		buckets: map[string]*addressBucket{},
+	}
+}
+
+func (l *addressLimiter) allow(r *http.Request) bool {
+	address := l.proxies.remoteAddress(r)
+	now := time.Now()
+
+	l.Lock()
+	defer l.Unlock()
+
+	bucket, ok := l.buckets[address]
+	if !ok {
+		if len(l.buckets) >= addressLimiterPruneSize {
+			l.prune(now)
+		}
+		bucket = &addressBucket{Bucket: ratelimit.New(l.rate, l.burst)}
+		l.buckets[address] = bucket
+	}
+	bucket.seen = now
+	return bucket.Allow()
+}
+
+// prune forgets addresses whose buckets would have refilled by now, since a
+// fresh bucket would treat them the same.
+func (l *addressLimiter) prune(now time.Time) {
+	idle := time.Duration(l.burst / l.rate * float64(time.Second))
+	for address, bucket := range l.buckets {
+		if now.Sub(bucket.seen) >= idle {
+			delete(l.buckets, address)
+		}
+	}
+}
+
+// connectionTracker counts open websockets per remote address, and keeps
+// hold of them so they can be closed when the server shuts down.
+type connectionTracker struct {
+	sync.Mutex
+	connections map[string]int
+	clients     map[*client]struct{}
+	handlers    sync.WaitGroup
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{
+		connections: map[string]int{},
+		clients:     map[*client]struct{}{},
+	}
+}
+
+func (l *connectionTracker) acquire(address string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.connections[address] >= maxConnectionsPerAddress {
+		return false
+	}
+	l.connections[address]++
+	return true
+}
+
+func (l *connectionTracker) release(address string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.connections[address]--; l.connections[address] <= 0 {
+		delete(l.connections, address)
+	}
+}
+
+func (l *connectionTracker) track(c *client) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.clients[c] = struct{}{}
+	l.handlers.Add(1)
+}
+
+func (l *connectionTracker) untrack(c *client) {
+	l.Lock()
+	defer l.Unlock()
+
+	delete(l.clients, c)
+	l.handlers.Done()
+}
+
+// closeAll asks every tracked websocket's writer to flush what is queued and
+// say goodbye, then waits up to the deadline for their handlers to return
+// before closing any stragglers outright.
+func (l *connectionTracker) closeAll(deadline time.Time) {
+	l.Lock()
+	for c := range l.clients {
+		close(c.goingAway)
+	}
+	l.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.handlers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Until(deadline)):
+		l.Lock()
+		for c := range l.clients {
+			c.Conn.Close()
+		}
+		l.Unlock()
+	}
+}
+
+// trustedProxies are the reverse proxies, such as a load balancer or a
+// hosting platform's router, whose X-Forwarded-For header is believed.
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies reads proxies given as addresses or CIDR ranges.
+func parseTrustedProxies(proxies []string) (trustedProxies, error) {
+	result := make(trustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+func (t trustedProxies) trusts(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddress returns the address a request came from. A request relayed by
+// a trusted proxy is attributed to the nearest address in its X-Forwarded-For
+// header that isn't itself a trusted proxy.
+func (t trustedProxies) remoteAddress(r *http.Request) string {
+	address := peerAddress(r)
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && t.trusts(address); i-- {
+		if hop := strings.TrimSpace(forwarded[i]); hop != "" {
+			address = hop
+		}
+	}
+	return address
+}
+
+// secure reports whether a request reached the server over HTTPS, either
+// directly or through a trusted proxy that terminated TLS and said so in
+// X-Forwarded-Proto.
+func (t trustedProxies) secure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !t.trusts(peerAddress(r)) {
+		return false
+	}
+
+	proto := strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+// peerAddress returns the address of whoever connected to the server, which
+// may be a proxy.
+func peerAddress(r *http.Request) string {
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return address
+}
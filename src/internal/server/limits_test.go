@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecure(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		peer   string
+		proto  string
+		tls    bool
+		secure bool
+	}{
+		{"203.0.113.7:5000", "", true, true},
+		{"203.0.113.7:5000", "", false, false},
+		{"203.0.113.7:5000", "https", false, false},
+		{"10.1.2.3:5000", "https", false, true},
+		{"10.1.2.3:5000", "HTTPS, http", false, true},
+		{"10.1.2.3:5000", "http", false, false},
+		{"10.1.2.3:5000", "", false, false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("POST", "/accounts/login", nil)
+		r.RemoteAddr = c.peer
+		if c.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+		if !c.tls {
+			r.TLS = nil
+		} else if r.TLS == nil {
+			r.TLS = &tls.ConnectionState{}
+		}
+
+		if secure := proxies.secure(r); secure != c.secure {
+			t.Errorf("request from %s with X-Forwarded-Proto %q and TLS %t is secure %t, want %t", c.peer, c.proto, c.tls, secure, c.secure)
+		}
+	}
+}
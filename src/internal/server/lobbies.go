@@ -0,0 +1,23 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"internal/engine"
+	"internal/log"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func lobbiesHandler(engine *engine.Engine) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		data, err := json.Marshal(engine.Lobbies())
+		if err != nil {
+			log.Fields{"error": err}.Panic("couldn't marshal lobby list")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// allowedOrigins lists the origins, besides the server's own, whose pages may
+// open a websocket. The upgrade carries the session cookie, so accepting any
+// origin would let other sites play as a signed-in visitor.
+type allowedOrigins map[string]struct{}
+
+// parseAllowedOrigins reads origins given as scheme://host[:port].
+func parseAllowedOrigins(origins []string) (allowedOrigins, error) {
+	result := allowedOrigins{}
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("invalid allowed origin %q", origin)
+		}
+		result[strings.ToLower(u.Scheme+"://"+u.Host)] = struct{}{}
+	}
+	return result, nil
+}
+
+// check reports whether a websocket upgrade may proceed: its Origin must be
+// the host it was sent to or an allowed origin. Requests without an Origin
+// don't come from a browser, and so can't be made on a visitor's behalf.
+func (a allowedOrigins) check(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	_, ok := a[strings.ToLower(u.Scheme+"://"+u.Host)]
+	return ok
+}
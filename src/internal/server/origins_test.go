@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedOrigins(t *testing.T) {
+	origins, err := parseAllowedOrigins([]string{"https://play.example.com", " http://localhost:3000 ", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		host   string
+		origin string
+		allow  bool
+	}{
+		{"goword.example.com", "", true},
+		{"goword.example.com", "https://goword.example.com", true},
+		{"goword.example.com", "https://GOWORD.example.com", true},
+		{"goword.example.com", "https://play.example.com", true},
+		{"goword.example.com", "http://localhost:3000", true},
+		{"goword.example.com", "https://evil.example.com", false},
+		{"goword.example.com", "http://localhost:3001", false},
+		{"goword.example.com", "https://goword.example.com.evil.example.com", false},
+		{"goword.example.com", "null", false},
+		{"goword.example.com", "%zz", false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "http://"+c.host+"/engine", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if allow := origins.check(r); allow != c.allow {
+			t.Errorf("origin %q on %s allowed %t, want %t", c.origin, c.host, allow, c.allow)
+		}
+	}
+}
+
+func TestParseAllowedOriginsRejectsMalformed(t *testing.T) {
+	for _, origin := range []string{"play.example.com", "https://", "https://play.example.com/lobby"} {
+		if _, err := parseAllowedOrigins([]string{origin}); err == nil {
+			t.Errorf("accepted malformed origin %q", origin)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"net/http"
+
+	"internal/account"
+	"internal/protocol"
+	"internal/rating"
+	"internal/stats"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const leaderboardSize = 50
+
+type playerStatsResponse struct {
+	ID       string      `json:"id"`
+	Username string      `json:"username"`
+	Period   string      `json:"period"`
+	Stats    stats.Stats `json:"stats"`
+}
+
+type leaderboardEntry struct {
+	Username string `json:"username"`
+	stats.Entry
+}
+
+type leaderboardResponse struct {
+	Period  string             `json:"period"`
+	Entries []leaderboardEntry `json:"entries"`
+}
+
+func period(r *http.Request) string {
+	if period := r.URL.Query().Get("period"); period != "" {
+		return period
+	}
+	return stats.PeriodAllTime
+}
+
+func playerStatsHandler(accounts account.Store, tracker *stats.Tracker) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		found, err := accounts.ByID(ps.ByName("id"))
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, errorResponse{protocol.ErrorNotFound, "There is no player with that ID"})
+			return
+		}
+
+		p := period(r)
+		result, err := tracker.Player(found.ID, p)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{protocol.ErrorBadArgument, err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, playerStatsResponse{
+			ID:       found.ID,
+			Username: found.Username,
+			Period:   p,
+			Stats:    result,
+		})
+	}
+}
+
+func leaderboardHandler(accounts account.Store, tracker *stats.Tracker) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		p := period(r)
+		entries, err := tracker.Leaderboard(p, leaderboardSize)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{protocol.ErrorBadArgument, err.Error()})
+			return
+		}
+
+		response := leaderboardResponse{
+			Period:  p,
+			Entries: make([]leaderboardEntry, len(entries)),
+		}
+		for i, entry := range entries {
+			response.Entries[i].Entry = entry
+			if found, err := accounts.ByID(entry.AccountID); err == nil {
+				response.Entries[i].Username = found.Username
+			}
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+type playerRatingResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	rating.Rating
+}
+
+type ratingsEntry struct {
+	Username string `json:"username"`
+	rating.Entry
+}
+
+type ratingsResponse struct {
+	Entries []ratingsEntry `json:"entries"`
+}
+
+func playerRatingHandler(accounts account.Store, ratings *rating.Table) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		found, err := accounts.ByID(ps.ByName("id"))
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, errorResponse{protocol.ErrorNotFound, "There is no player with that ID"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, playerRatingResponse{
+			ID:       found.ID,
+			Username: found.Username,
+			Rating:   ratings.Get(found.ID),
+		})
+	}
+}
+
+func ratingsHandler(accounts account.Store, ratings *rating.Table) func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		entries := ratings.Top(leaderboardSize)
+
+		response := ratingsResponse{
+			Entries: make([]ratingsEntry, len(entries)),
+		}
+		for i, entry := range entries {
+			response.Entries[i].Entry = entry
+			if found, err := accounts.ByID(entry.AccountID); err == nil {
+				response.Entries[i].Username = found.Username
+			}
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
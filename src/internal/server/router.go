@@ -5,13 +5,17 @@ import (
 	"net"
 	"net/http"
 
+	"internal/challenge"
 	"internal/engine"
 	"internal/log"
+	"internal/rating"
+	"internal/stats"
+	"internal/store"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-func router(engine *engine.Engine) http.Handler {
+func router(engine *engine.Engine, connections *connectionTracker, accounts *accounts, tracker *stats.Tracker, ratings *rating.Table, history store.Store, daily *challenge.Daily, proxies trustedProxies, origins allowedOrigins) http.Handler {
 	router := httprouter.New()
 
 	router.GET("/internal/grid/", gridHandler)
@@ -19,7 +23,19 @@ func router(engine *engine.Engine) http.Handler {
 	for route := range staticRoutes {
 		router.GET(route, staticHandler)
 	}
-	router.GET("/engine", engineHandler(engine))
+	router.GET("/engine", engineHandler(engine, connections, accounts, proxies, origins))
+	router.GET("/lobbies", lobbiesHandler(engine))
+	credentials := newAddressLimiter(credentialRate, credentialBurst, proxies)
+	router.POST("/accounts/register", registerHandler(accounts, credentials))
+	router.POST("/accounts/login", loginHandler(accounts, credentials))
+	router.POST("/accounts/logout", logoutHandler(accounts))
+	router.GET("/players/:id/stats", playerStatsHandler(accounts.store, tracker))
+	router.GET("/leaderboard", leaderboardHandler(accounts.store, tracker))
+	router.GET("/players/:id/rating", playerRatingHandler(accounts.store, ratings))
+	router.GET("/ratings", ratingsHandler(accounts.store, ratings))
+	router.GET("/games", gamesHandler(history))
+	router.GET("/games/:id/replay", replayHandler(history))
+	router.GET("/challenge/leaderboard", challengeLeaderboardHandler(accounts.store, daily))
 
 	router.RedirectTrailingSlash = true
 	router.RedirectFixedPath = true
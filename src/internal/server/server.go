@@ -1,34 +1,235 @@
 package server
 
 import (
+	"context"
 	stdlog "log"
 	"net/http"
+	"path"
 	"time"
 
+	"internal/account"
+	"internal/challenge"
 	"internal/engine"
+	"internal/language"
 	"internal/log"
+	"internal/nickname"
+	"internal/rating"
+	"internal/stats"
+	"internal/store"
 )
 
-func Server(address string) error {
-	log.Fields{"address": address}.Info("starting http server")
+// closeWait bounds how long the server waits for websockets to close once
+// games have drained.
+const closeWait = 5 * time.Second
 
-	engine := engine.New()
-	go engine.Run()
-	defer engine.Terminate()
+type Config struct {
+	Address string
+
+	// Grace is how long games in progress may run on once shutdown begins.
+	Grace time.Duration
+
+	// HistoryPath names the file finished games are recorded in; if empty,
+	// they are kept in memory only.
+	HistoryPath string
+
+	// AccountsPath and SessionKeyPath name the files accounts and the key
+	// that signs their sessions are kept in; if empty, they last only as long
+	// as the process.
+	AccountsPath   string
+	SessionKeyPath string
+
+	// ChallengesPath names the file daily challenge attempts are kept in, and
+	// ChallengeKeyPath the key each day's board is derived from; if empty,
+	// they last only as long as the process.
+	ChallengesPath   string
+	ChallengeKeyPath string
+
+	// TrustedProxies lists the addresses and CIDR ranges of reverse proxies
+	// in front of the server. Requests they relay are attributed to the
+	// client named in X-Forwarded-For, so that per-address limits apply to
+	// clients rather than to the proxy.
+	TrustedProxies []string
+
+	// AllowedOrigins lists the origins, as scheme://host[:port], of pages
+	// other than the server's own that may open a websocket.
+	AllowedOrigins []string
+}
+
+// Server serves until stop is closed, then drains: it stops accepting
+// connections, lets games in progress finish for up to the grace period, and
+// closes the remaining websockets before returning.
+func Server(config Config, stop <-chan struct{}) error {
+	log.Fields{"address": config.Address}.Info("starting http server")
+
+	proxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		log.Fields{"error": err}.Error("couldn't parse trusted proxies")
+		return err
+	}
+
+	origins, err := parseAllowedOrigins(config.AllowedOrigins)
+	if err != nil {
+		log.Fields{"error": err}.Error("couldn't parse allowed origins")
+		return err
+	}
+
+	if err := language.Load(path.Join("config", "languages")); err != nil {
+		log.Fields{"error": err}.Error("couldn't load language packs")
+		return err
+	}
+
+	if err := nickname.Load("config"); err != nil {
+		log.Fields{"error": err}.Error("couldn't load nickname lists")
+		return err
+	}
+
+	history, err := openHistory(config.HistoryPath)
+	if err != nil {
+		log.Fields{"path": config.HistoryPath, "error": err}.Error("couldn't open game history")
+		return err
+	}
+
+	defer history.Close()
+
+	accounts, err := openAccounts(config.AccountsPath, config.SessionKeyPath, proxies)
+	if err != nil {
+		log.Fields{"path": config.AccountsPath, "error": err}.Error("couldn't open accounts")
+		return err
+	}
+	defer accounts.store.Close()
+
+	daily, err := openChallenge(config.ChallengesPath, config.ChallengeKeyPath)
+	if err != nil {
+		log.Fields{"path": config.ChallengesPath, "error": err}.Error("couldn't open daily challenge")
+		return err
+	}
+	defer daily.Close()
+
+	tracker, ratings, err := replayHistory(history)
+	if err != nil {
+		log.Fields{"path": config.HistoryPath, "error": err}.Error("couldn't read game history")
+		return err
+	}
+
+	e := engine.New()
+	e.SetStore(tracker.Wrap(history))
+	e.SetAccounts(accounts.store)
+	e.SetRatings(ratings)
+	e.SetChallenge(daily)
+	e.AddChatFilter(engine.QuietDuringGames{})
+	if blocklist, err := engine.LoadBlocklist(path.Join("config", "chat-blocklist.list")); err != nil {
+		log.Fields{"error": err}.Info("no chat blocklist loaded")
+	} else {
+		e.AddChatFilter(blocklist)
+	}
+	go e.Run()
+	defer e.Terminate()
+
+	connections := newConnectionTracker()
 
 	w := log.Writer()
 	defer w.Close()
 	s := &http.Server{
-		Addr:           address,
-		Handler:        router(engine),
+		Addr:           config.Address,
+		Handler:        router(e, connections, accounts, tracker, ratings, history, daily, proxies, origins),
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 		ErrorLog:       stdlog.New(w, "", 0),
 	}
-	if err := s.ListenAndServe(); err != nil {
+
+	serveErrors := make(chan error, 1)
+	go func() { serveErrors <- s.ListenAndServe() }()
+
+	select {
+	case err := <-serveErrors:
 		log.Fields{"error": err}.Error("unexpected error from http server")
 		return err
+	case <-stop:
+	}
+
+	deadline := time.Now().Add(config.Grace)
+	log.Fields{"deadline": deadline}.Info("shutting down http server")
+
+	// Websockets are hijacked, so Shutdown only closes the listener and waits
+	// for plain requests; the engine's sockets are drained separately.
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Fields{"error": err}.Error("http server did not shut down cleanly")
+		return err
 	}
+
+	e.Shutdown(deadline)
+	connections.closeAll(time.Now().Add(closeWait))
+
+	log.Info("http server has shut down")
 	return nil
 }
+
+func openHistory(path string) (store.Store, error) {
+	if path == "" {
+		return store.NewMemory(), nil
+	}
+	return store.OpenFile(path)
+}
+
+// replayHistory rebuilds player statistics and ratings from every recorded
+// game.
+func replayHistory(history store.Store) (*stats.Tracker, *rating.Table, error) {
+	games, err := history.Games(time.Time{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracker := stats.New()
+	ratings := rating.New()
+	for i := range games {
+		tracker.Record(games[i])
+		ratings.Record(&games[i])
+	}
+	log.Fields{"games": len(games)}.Info("loaded player statistics and ratings from game history")
+	return tracker, ratings, nil
+}
+
+func openAccounts(path, keyPath string, proxies trustedProxies) (*accounts, error) {
+	var s account.Store = account.NewMemory()
+	if path != "" {
+		f, err := account.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s = f
+	}
+
+	sessions, err := account.LoadSessions(keyPath, sessionLifetime)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return &accounts{
+		store:    s,
+		sessions: sessions,
+		proxies:  proxies,
+	}, nil
+}
+
+func openChallenge(path, keyPath string) (*challenge.Daily, error) {
+	var s challenge.Store = challenge.NewMemory()
+	if path != "" {
+		f, err := challenge.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s = f
+	}
+
+	key, err := challenge.LoadKey(keyPath)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return challenge.New(key, s), nil
+}
@@ -29,8 +29,8 @@ var staticRoutes = map[string]staticFile{
 	"/compass.svg": {path.Join("static", "compass.svg"), "image/svg+xml"},
 	"/skull.svg":   {path.Join("static", "skull.svg"), "image/svg+xml"},
 
-	"/cubes.json": {path.Join("config", "cubes.json"), "application/json"},
-	"/words.list": {path.Join("config", "words.list"), "text/plain"},
+	"/cubes.json": {path.Join("config", "languages", "en", "cubes-4x4.json"), "application/json"},
+	"/words.list": {path.Join("config", "languages", "en", "dictionaries", "english.list"), "text/plain"},
 }
 
 func staticHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -0,0 +1,243 @@
+// Package stats aggregates per-account statistics from finished games.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"internal/store"
+)
+
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodAllTime = "all-time"
+
+	dayFormat = "2006-01-02"
+)
+
+var ErrUnknownPeriod = errors.New("period must be one of daily, weekly, all-time")
+
+type Stats struct {
+	GamesPlayed int    `json:"gamesPlayed"`
+	Wins        int    `json:"wins"`
+	TotalScore  int    `json:"totalScore"`
+	BestScore   int    `json:"bestScore"`
+	LongestWord string `json:"longestWord,omitempty"`
+	WordsFound  int    `json:"wordsFound"`
+	UniqueWords int    `json:"uniqueWords"`
+}
+
+func (s *Stats) add(other *Stats) {
+	if s.GamesPlayed == 0 || other.BestScore > s.BestScore {
+		s.BestScore = other.BestScore
+	}
+	s.GamesPlayed += other.GamesPlayed
+	s.Wins += other.Wins
+	s.TotalScore += other.TotalScore
+	if utf8.RuneCountInString(other.LongestWord) > utf8.RuneCountInString(s.LongestWord) {
+		s.LongestWord = other.LongestWord
+	}
+	s.WordsFound += other.WordsFound
+	s.UniqueWords += other.UniqueWords
+}
+
+func (s Stats) MarshalJSON() ([]byte, error) {
+	average, rate := 0.0, 0.0
+	if s.GamesPlayed > 0 {
+		average = float64(s.WordsFound) / float64(s.GamesPlayed)
+	}
+	if s.WordsFound > 0 {
+		rate = float64(s.UniqueWords) / float64(s.WordsFound)
+	}
+
+	type Alias Stats
+	return json.Marshal(&struct {
+		AverageWordsPerGame float64 `json:"averageWordsPerGame"`
+		UniqueWordRate      float64 `json:"uniqueWordRate"`
+		Alias
+	}{
+		AverageWordsPerGame: average,
+		UniqueWordRate:      rate,
+		Alias:               (Alias)(s),
+	})
+}
+
+// Tracker keeps each account's statistics bucketed by day (UTC), so that
+// daily and weekly windows can be summed on demand.
+type Tracker struct {
+	sync.RWMutex
+	days map[string]map[string]*Stats
+}
+
+func New() *Tracker {
+	return &Tracker{
+		days: map[string]map[string]*Stats{},
+	}
+}
+
+// Record folds a finished game into the statistics of every signed-in player
+// who took part. Players who scored the most in a game with at least two
+// players are credited with a win; a word is counted as found if it scored no
+// penalty, and as unique if nobody else found it.
+func (t *Tracker) Record(game store.Game) {
+	best := 0
+	for i, player := range game.Players {
+		if i == 0 || player.Score > best {
+			best = player.Score
+		}
+	}
+
+	day := game.EndedAt.UTC().Format(dayFormat)
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, player := range game.Players {
+		if player.AccountID == "" {
+			continue
+		}
+
+		result := Stats{
+			GamesPlayed: 1,
+			TotalScore:  player.Score,
+			BestScore:   player.Score,
+		}
+		if len(game.Players) > 1 && player.Score == best {
+			result.Wins = 1
+		}
+		for _, word := range player.Words {
+			if word.Points < 0 {
+				continue
+			}
+			result.WordsFound++
+			if word.Points > 0 {
+				result.UniqueWords++
+			}
+			if utf8.RuneCountInString(word.Word) > utf8.RuneCountInString(result.LongestWord) {
+				result.LongestWord = word.Word
+			}
+		}
+
+		days, ok := t.days[player.AccountID]
+		if !ok {
+			days = map[string]*Stats{}
+			t.days[player.AccountID] = days
+		}
+		if total, ok := days[day]; ok {
+			total.add(&result)
+		} else {
+			days[day] = &result
+		}
+	}
+}
+
+// Wrap returns a store that records games in s and in the tracker.
+func (t *Tracker) Wrap(s store.Store) store.Store {
+	return recording{s, t}
+}
+
+type recording struct {
+	store.Store
+	tracker *Tracker
+}
+
+func (r recording) RecordGame(game store.Game) error {
+	if err := r.Store.RecordGame(game); err != nil {
+		return err
+	}
+	r.tracker.Record(game)
+	return nil
+}
+
+// Player returns an account's statistics over a period ending now.
+func (t *Tracker) Player(accountID, period string) (Stats, error) {
+	since, err := periodStart(period, time.Now())
+	if err != nil {
+		return Stats{}, err
+	}
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.sum(accountID, since), nil
+}
+
+type Entry struct {
+	Rank      int    `json:"rank"`
+	AccountID string `json:"accountId"`
+	Stats     Stats  `json:"stats"`
+}
+
+// Leaderboard ranks accounts that played during a period by total score,
+// then by wins, returning at most limit entries. Accounts with equal score
+// and wins share a rank.
+func (t *Tracker) Leaderboard(period string, limit int) ([]Entry, error) {
+	since, err := periodStart(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	t.RLock()
+	entries := []Entry{}
+	for accountID := range t.days {
+		if stats := t.sum(accountID, since); stats.GamesPlayed > 0 {
+			entries = append(entries, Entry{AccountID: accountID, Stats: stats})
+		}
+	}
+	t.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].Stats, entries[j].Stats
+		if a.TotalScore != b.TotalScore {
+			return a.TotalScore > b.TotalScore
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return entries[i].AccountID < entries[j].AccountID
+	})
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 {
+			previous := entries[i-1]
+			if previous.Stats.TotalScore == entries[i].Stats.TotalScore && previous.Stats.Wins == entries[i].Stats.Wins {
+				entries[i].Rank = previous.Rank
+			}
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (t *Tracker) sum(accountID, since string) Stats {
+	total := Stats{}
+	for day, stats := range t.days[accountID] {
+		if day >= since {
+			total.add(stats)
+		}
+	}
+	return total
+}
+
+// periodStart returns the first day, formatted for comparison with bucket
+// keys, that falls within the period.
+func periodStart(period string, now time.Time) (string, error) {
+	now = now.UTC()
+	switch period {
+	case PeriodDaily:
+		return now.Format(dayFormat), nil
+	case PeriodWeekly:
+		return now.AddDate(0, 0, -6).Format(dayFormat), nil
+	case PeriodAllTime, "":
+		return "", nil
+	}
+	return "", ErrUnknownPeriod
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// File appends games to a file as JSON lines, and keeps an index of where
+// each one starts so lookups read a single line.
+type File struct {
+	sync.Mutex
+	file    *os.File
+	size    int64
+	index   map[string]int64
+	entries []fileEntry
+}
+
+type fileEntry struct {
+	offset     int64
+	endedAt    time.Time
+	lobby      string
+	accountIDs []string
+}
+
+func newFileEntry(offset int64, game Game) fileEntry {
+	return fileEntry{
+		offset:     offset,
+		endedAt:    game.EndedAt,
+		lobby:      game.Lobby,
+		accountIDs: game.accountIDs(),
+	}
+}
+
+func OpenFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		file:  file,
+		index: map[string]int64{},
+	}
+	if err = f.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) load() error {
+	reader := bufio.NewReader(f.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A partial trailing line is left over from a crash mid-write;
+			// drop it so the next record starts on a fresh line.
+			return f.file.Truncate(f.size)
+		} else if err != nil {
+			return err
+		}
+
+		var game Game
+		if err = json.Unmarshal(line, &game); err != nil {
+			return err
+		}
+
+		f.index[game.ID] = f.size
+		f.entries = append(f.entries, newFileEntry(f.size, game))
+		f.size += int64(len(line))
+	}
+}
+
+func (f *File) RecordGame(game Game) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f.Lock()
+	defer f.Unlock()
+
+	if _, err = f.file.WriteAt(data, f.size); err != nil {
+		return err
+	}
+
+	f.index[game.ID] = f.size
+	f.entries = append(f.entries, newFileEntry(f.size, game))
+	f.size += int64(len(data))
+	return nil
+}
+
+func (f *File) Game(id string) (Game, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	offset, ok := f.index[id]
+	if !ok {
+		return Game{}, ErrNotFound
+	}
+	return f.read(offset)
+}
+
+func (f *File) Games(since time.Time) ([]Game, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	games := []Game{}
+	for _, entry := range f.entries {
+		if entry.endedAt.Before(since) {
+			continue
+		}
+
+		game, err := f.read(entry.offset)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+func (f *File) Recent(q Query) ([]Game, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	games := []Game{}
+	for i := len(f.entries) - 1; i >= 0 && len(games) < q.Limit; i-- {
+		entry := f.entries[i]
+		if !q.matches(entry.lobby, entry.accountIDs) {
+			continue
+		}
+
+		game, err := f.read(entry.offset)
+		if err != nil {
+			return nil, err
+		}
+		game.Events = nil
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+func (f *File) read(offset int64) (Game, error) {
+	reader := bufio.NewReader(io.NewSectionReader(f.file, offset, f.size-offset))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return Game{}, err
+	}
+
+	var game Game
+	err = json.Unmarshal(line, &game)
+	return game, err
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory keeps games in memory only; it is meant for tests and for running
+// without a history file.
+type Memory struct {
+	sync.RWMutex
+	games []Game
+	index map[string]int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		index: map[string]int{},
+	}
+}
+
+func (m *Memory) RecordGame(game Game) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.index[game.ID] = len(m.games)
+	m.games = append(m.games, game)
+	return nil
+}
+
+func (m *Memory) Game(id string) (Game, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	i, ok := m.index[id]
+	if !ok {
+		return Game{}, ErrNotFound
+	}
+	return m.games[i], nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) Games(since time.Time) ([]Game, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	games := []Game{}
+	for _, game := range m.games {
+		if !game.EndedAt.Before(since) {
+			games = append(games, game)
+		}
+	}
+	return games, nil
+}
+
+func (m *Memory) Recent(q Query) ([]Game, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	games := []Game{}
+	for i := len(m.games) - 1; i >= 0 && len(games) < q.Limit; i-- {
+		game := m.games[i]
+		if q.matches(game.Lobby, game.accountIDs()) {
+			game.Events = nil
+			games = append(games, game)
+		}
+	}
+	return games, nil
+}
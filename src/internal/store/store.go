@@ -0,0 +1,119 @@
+// Package store records finished games.
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("no game with that ID")
+
+type Store interface {
+	RecordGame(game Game) error
+	Game(id string) (Game, error)
+
+	// Games returns every game that ended at or after since, oldest first.
+	Games(since time.Time) ([]Game, error)
+
+	// Recent returns up to q.Limit of the latest games matching q, newest
+	// first and without their timelines.
+	Recent(q Query) ([]Game, error)
+
+	Close() error
+}
+
+// Query selects games by lobby or by an account that played them; empty
+// fields match every game.
+type Query struct {
+	Lobby     string
+	AccountID string
+	Limit     int
+}
+
+func (q Query) matches(lobby string, accountIDs []string) bool {
+	if q.Lobby != "" && q.Lobby != lobby {
+		return false
+	}
+	if q.AccountID == "" {
+		return true
+	}
+	for _, accountID := range accountIDs {
+		if accountID == q.AccountID {
+			return true
+		}
+	}
+	return false
+}
+
+type Game struct {
+	ID         string     `json:"id"`
+	Lobby      string     `json:"lobby"`
+	Language   string     `json:"language"`
+	Dictionary string     `json:"dictionary"`
+	Size       int        `json:"size"`
+	Grid       [][]string `json:"grid"`
+	Seed       int64      `json:"seed"`
+	Settings   Settings   `json:"settings"`
+	StartedAt  time.Time  `json:"startedAt"`
+	EndedAt    time.Time  `json:"endedAt"`
+	Players    []Player   `json:"players"`
+	Events     []Event    `json:"events,omitempty"`
+}
+
+// Settings durations are in seconds.
+type Settings struct {
+	GameDuration         int `json:"gameDuration"`
+	CountdownDuration    int `json:"countdownDuration"`
+	IntermissionDuration int `json:"intermissionDuration"`
+	MinimumPlayers       int `json:"minimumPlayers"`
+}
+
+// accountIDs returns the accounts of the game's signed-in players.
+func (g Game) accountIDs() []string {
+	ids := []string{}
+	for _, player := range g.Players {
+		if player.AccountID != "" {
+			ids = append(ids, player.AccountID)
+		}
+	}
+	return ids
+}
+
+type Player struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId,omitempty"`
+	Nickname  string `json:"nickname"`
+	Score     int    `json:"score"`
+	Words     []Word `json:"words"`
+
+	// Rating and RatingChange are the player's rating after the game and how
+	// far it moved; they are zero if the game was unrated.
+	Rating       int `json:"rating,omitempty"`
+	RatingChange int `json:"ratingChange,omitempty"`
+}
+
+type Word struct {
+	Word   string `json:"word"`
+	Points int    `json:"points"`
+}
+
+const (
+	EventStart  = "start"
+	EventEnd    = "end"
+	EventJoin   = "join"
+	EventPart   = "part"
+	EventDetach = "detach"
+	EventResume = "resume"
+	EventWord   = "word"
+)
+
+// Event is one entry in a game's timeline. Offset is in milliseconds since the
+// game started; Status is the outcome of a submitted word.
+type Event struct {
+	Offset   int64  `json:"offset"`
+	Type     string `json:"type"`
+	PlayerID string `json:"playerId,omitempty"`
+	Nickname string `json:"nickname,omitempty"`
+	Word     string `json:"word,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
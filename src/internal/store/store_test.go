@@ -0,0 +1,218 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func testGame(id string, endedAt time.Time) Game {
+	return Game{
+		ID:         id,
+		Lobby:      "lobby",
+		Language:   "en",
+		Dictionary: "english",
+		Size:       2,
+		Grid:       [][]string{{"A", "B"}, {"C", "D"}},
+		Seed:       7,
+		Settings:   Settings{GameDuration: 90, CountdownDuration: 5, IntermissionDuration: 30, MinimumPlayers: 2},
+		StartedAt:  endedAt.Add(-90 * time.Second),
+		EndedAt:    endedAt,
+		Players: []Player{{
+			ID:       "p1",
+			Nickname: "Alice",
+			Score:    1,
+			Words:    []Word{{Word: "CAB", Points: 1}},
+		}},
+		Events: []Event{{Offset: 1500, Type: EventWord, PlayerID: "p1", Word: "CAB", Status: "valid"}},
+	}
+}
+
+func tempFile(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(directory, "games.jsonl"), func() { os.RemoveAll(directory) }
+}
+
+// testStore records three games in s and checks they can be read back.
+func testStore(t *testing.T, s Store) {
+	games := []Game{
+		testGame("first", epoch),
+		testGame("second", epoch.Add(time.Hour)),
+		testGame("third", epoch.Add(2*time.Hour)),
+	}
+	games[1].Players[0].AccountID = "account"
+	for _, game := range games {
+		if err := s.RecordGame(game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range games {
+		got, err := s.Game(want.ID)
+		if err != nil {
+			t.Fatalf("couldn't read game %q: %s", want.ID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("game %q is %+v, want %+v", want.ID, got, want)
+		}
+	}
+
+	if _, err := s.Game("missing"); err != ErrNotFound {
+		t.Errorf("reading a missing game returned %v, want ErrNotFound", err)
+	}
+
+	since, err := s.Games(epoch.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(since, games[1:]) {
+		t.Errorf("games since the second are %+v, want %+v", since, games[1:])
+	}
+
+	all, err := s.Games(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all, games) {
+		t.Errorf("all games are %+v, want %+v", all, games)
+	}
+
+	summaries := make([]Game, len(games))
+	for i, game := range games {
+		game.Events = nil
+		summaries[len(games)-1-i] = game
+	}
+
+	recent := []struct {
+		query Query
+		want  []Game
+	}{
+		{Query{Limit: 2}, summaries[:2]},
+		{Query{Limit: 10}, summaries},
+		{Query{Lobby: "lobby", Limit: 10}, summaries},
+		{Query{Lobby: "elsewhere", Limit: 10}, []Game{}},
+		{Query{AccountID: "account", Limit: 10}, summaries[1:2]},
+		{Query{Lobby: "lobby", AccountID: "nobody", Limit: 10}, []Game{}},
+	}
+	for _, c := range recent {
+		got, err := s.Recent(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("recent games matching %+v are %+v, want %+v", c.query, got, c.want)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, f)
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Games survive reopening the file.
+	f, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	games, err := f.Games(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("reopened file holds %d games, want 3", len(games))
+	}
+	if game, err := f.Game("second"); err != nil || !reflect.DeepEqual(game, games[1]) {
+		t.Errorf("reopened file returned %+v, %v for the second game", game, err)
+	}
+
+	recent, err := f.Recent(Query{AccountID: "account", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].ID != "second" {
+		t.Errorf("reopened file returned %+v as the account's games, want the second game", recent)
+	}
+}
+
+func TestFileTruncatesPartialLine(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.RecordGame(testGame("complete", epoch)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	complete, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash mid-write leaves part of a line behind.
+	partial := append(append([]byte{}, complete...), []byte(`{"id":"partial","lobby":"lo`)...)
+	if err = ioutil.WriteFile(path, partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("couldn't open a file with a partial trailing line: %s", err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != string(complete) {
+		t.Errorf("file holds %q after opening, want the partial line dropped", contents)
+	}
+
+	if err = f.RecordGame(testGame("next", epoch.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	f, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("couldn't reopen the file: %s", err)
+	}
+	defer f.Close()
+
+	games, err := f.Games(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 || games[0].ID != "complete" || games[1].ID != "next" {
+		t.Errorf("file holds %+v, want the complete game followed by the next", games)
+	}
+	if _, err = f.Game("partial"); err != ErrNotFound {
+		t.Errorf("reading the partial game returned %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,97 @@
+package wordlist
+
+import "sort"
+
+type Trie struct {
+	nodes []trieNode
+	edges []trieEdge
+}
+
+type trieNode struct {
+	firstEdge int32
+	edgeCount int32
+	terminal  bool
+}
+
+type trieEdge struct {
+	label byte
+	child int32
+}
+
+type Node int32
+
+const Root Node = 0
+
+func (list Wordlist) Trie() *Trie {
+	words := make([]string, len(list))
+	copy(words, list)
+	sort.Strings(words)
+
+	t := &Trie{}
+	t.build(words, 0)
+	return t
+}
+
+func (t *Trie) build(words []string, depth int) int32 {
+	index := int32(len(t.nodes))
+	t.nodes = append(t.nodes, trieNode{})
+
+	for len(words) != 0 && len(words[0]) == depth {
+		t.nodes[index].terminal = true
+		words = words[1:]
+	}
+
+	groups := [][]string{}
+	for len(words) != 0 {
+		label := words[0][depth]
+		end := 1
+		for end < len(words) && words[end][depth] == label {
+			end++
+		}
+		groups = append(groups, words[:end])
+		words = words[end:]
+	}
+
+	first := int32(len(t.edges))
+	t.nodes[index].firstEdge = first
+	t.nodes[index].edgeCount = int32(len(groups))
+	for _, group := range groups {
+		t.edges = append(t.edges, trieEdge{label: group[0][depth]})
+	}
+
+	for i, group := range groups {
+		t.edges[first+int32(i)].child = t.build(group, depth+1)
+	}
+
+	return index
+}
+
+func (t *Trie) Walk(n Node, delta string) (Node, bool) {
+	for i := 0; i < len(delta); i++ {
+		node := &t.nodes[n]
+		edges := t.edges[node.firstEdge : node.firstEdge+node.edgeCount]
+
+		k := sort.Search(len(edges), func(k int) bool {
+			return edges[k].label >= delta[i]
+		})
+		if k == len(edges) || edges[k].label != delta[i] {
+			return n, false
+		}
+
+		n = Node(edges[k].child)
+	}
+	return n, true
+}
+
+func (t *Trie) Terminal(n Node) bool {
+	return t.nodes[n].terminal
+}
+
+func (t *Trie) Leaf(n Node) bool {
+	return t.nodes[n].edgeCount == 0
+}
+
+func (t *Trie) Contains(word string) bool {
+	n, ok := t.Walk(Root, word)
+	return ok && t.Terminal(n)
+}
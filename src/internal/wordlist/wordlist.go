@@ -8,13 +8,16 @@ import (
 
 type Wordlist []string
 
-func FromFile(path string) (Wordlist, error) {
+func FromFile(path string, fold func(string) string) (Wordlist, error) {
 	blob, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	words := strings.Split(strings.ToUpper(strings.Trim(string(blob), "\n")), "\n")
+	words := strings.Split(strings.Trim(strings.Replace(string(blob), "\r", "", -1), "\n"), "\n")
+	for i, word := range words {
+		words[i] = fold(word)
+	}
 	sort.Strings(words)
 	return words, nil
 }